@@ -25,8 +25,23 @@ type Action interface {
 
 	// Does this action need to be within range of a target game object?
 	// If not then the moveTo state will not need to run for this action.
+	RequiresInRange() bool
+
+	// Is the agent within range of the target, so that the action can be performed. Only asked
+	// for actions that require being in range.
 	InRange(Agent) bool
 
+	// How the FSM reacts when Perform returns false. See RetryPolicy.
+	RetryPolicy() RetryPolicy
+
+	// How many updates must pass after the action is done before it can be planned again. Only
+	// used when planning with Cooldowns.
+	Cooldown() int
+
+	// How long the action takes to perform, in whatever time unit the game uses. Used by the
+	// planner when planning against a deadline or minimising the makespan.
+	Duration() float64
+
 	SetTarget(interface{})
 	Target() interface{}
 
@@ -41,6 +56,28 @@ type Action interface {
 	String() string
 }
 
+// FailurePolicy decides what happens when an action has failed and has no retries left.
+type FailurePolicy int
+
+const (
+	// AbortPlan resets the FSM to Idle and calls Agent.PlanAborted. This is the default.
+	AbortPlan FailurePolicy = iota
+	// SkipAction drops the failed action and carries on with the rest of the plan.
+	SkipAction
+)
+
+// RetryPolicy describes how often a failing action is retried before its FailurePolicy is applied.
+// The zero value gives the old behaviour: no retries and the plan is aborted.
+type RetryPolicy struct {
+	// How many times Perform is retried after it returned false.
+	Retries int
+	// Number of updates to wait before retrying. The wait grows with each retry, so the second
+	// retry waits 2*Backoff updates, the third 3*Backoff and so on.
+	Backoff int
+	// What to do when all retries have failed.
+	OnFailure FailurePolicy
+}
+
 // NewAction create a new base DefaultAction
 func NewAction(name string, cost float64) DefaultAction {
 	return DefaultAction{
@@ -58,7 +95,14 @@ type DefaultAction struct {
 	cost            float64
 	Done            bool
 	requiresInRange bool
+	radius          float64
+	rangeChecker    RangeChecker
 	target          interface{}
+	retryPolicy     RetryPolicy
+	cooldown        int
+	duration        float64
+	// the definition this is an instance of, see NewInstance
+	definedBy Action
 }
 
 func (a *DefaultAction) Reset() {
@@ -110,10 +154,85 @@ func (a *DefaultAction) Target() interface{} {
 	return a.target
 }
 
+// SetRequiresInRange marks the action as one that has to be within range of its target before it
+// can be performed. Actions don't require range by default.
+func (a *DefaultAction) SetRequiresInRange(r bool) {
+	a.requiresInRange = r
+}
+
+func (a *DefaultAction) RequiresInRange() bool {
+	return a.requiresInRange
+}
+
+// SetRange sets how close the agent must be to the target, it's passed to the range checker.
+func (a *DefaultAction) SetRange(radius float64) {
+	a.radius = radius
+}
+
+func (a *DefaultAction) Range() float64 {
+	return a.radius
+}
+
+// SetRangeChecker replaces the DistanceRangeChecker used by InRange.
+func (a *DefaultAction) SetRangeChecker(c RangeChecker) {
+	a.rangeChecker = c
+}
+
+// InRange is always true for actions that don't require range. Otherwise the range checker decides
+// if the agent is within Range of the target. Without a target the agent is never in range.
+func (a *DefaultAction) InRange(agent Agent) bool {
+	if !a.requiresInRange {
+		return true
+	}
+	if a.target == nil {
+		return false
+	}
+	if a.rangeChecker != nil {
+		return a.rangeChecker(agent, a.target, a.radius)
+	}
+	return DistanceRangeChecker(agent, a.target, a.radius)
+}
+
 func (a *DefaultAction) CheckContextPrecondition(agent Agent) bool {
 	return true
 }
 
+func (a *DefaultAction) SetRetryPolicy(p RetryPolicy) {
+	a.retryPolicy = p
+}
+
+func (a *DefaultAction) RetryPolicy() RetryPolicy {
+	return a.retryPolicy
+}
+
+func (a *DefaultAction) SetCooldown(updates int) {
+	a.cooldown = updates
+}
+
+func (a *DefaultAction) Cooldown() int {
+	return a.cooldown
+}
+
+func (a *DefaultAction) SetDuration(d float64) {
+	a.duration = d
+}
+
+func (a *DefaultAction) Duration() float64 {
+	return a.duration
+}
+
+func (a *DefaultAction) setDefinition(d Action) {
+	a.definedBy = d
+}
+
+func (a *DefaultAction) definitionOf() Action {
+	return a.definedBy
+}
+
+func (a *DefaultAction) defaultAction() *DefaultAction {
+	return a
+}
+
 func (a *DefaultAction) String() string {
 	return a.name
 }
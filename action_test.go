@@ -5,6 +5,7 @@ func newGetFoodAction(cost float64) *getFoodAction {
 		DefaultAction: NewAction("getFood", cost),
 		inRange:       false,
 	}
+	a.SetRequiresInRange(true)
 	return a
 }
 
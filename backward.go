@@ -0,0 +1,119 @@
+package goap
+
+import (
+	"math/bits"
+)
+
+// backward returns true if the search goes from the goal to the world state
+func (s *search) backward() bool {
+	return s.Direction == BackwardSearch && s.TravelCost == nil && s.conflicts == nil
+}
+
+// goalNode returns the root node of a backward search, its state is the goal
+func (s *search) goalNode() *node {
+	root := s.arena.node()
+	root.state = s.arena.state(2 * s.index.words)
+	copy(root.state.known(), s.goal.mask)
+	copy(root.state.values(), s.goal.values)
+	root.location = s.agent
+	return root
+}
+
+// buildBackward is buildGraph for a backward search. The state of each node holds what is needed
+// before its action, and the leaves are the nodes whose needs are met by the world state.
+func (s *search) buildBackward(parent *node, leaves *[]*node) bool {
+	foundOne := false
+	needed, values := parent.state.known(), parent.state.values()
+
+	// only the actions that produce something that's needed are relevant
+	relevant := s.arena.state(s.actions.words)
+	for i := range relevant {
+		relevant[i] = 0
+	}
+	for w, word := range needed {
+		for ; word != 0; word &= word - 1 {
+			for j, producers := range s.actions.producers[w*64+bits.TrailingZeros64(word)] {
+				relevant[j] |= producers
+			}
+		}
+	}
+
+	for w, word := range relevant {
+		for ; word != 0; word &= word - 1 {
+			i := w*64 + bits.TrailingZeros64(word)
+			if s.steps[i].used || !s.steps[i].regresses(needed, values) {
+				continue
+			}
+			if s.expandBackward(parent, leaves, i) {
+				foundOne = true
+			}
+		}
+	}
+	return foundOne
+}
+
+// expandBackward puts the i:th action before the parent node, it returns true if at least one
+// solution was found
+func (s *search) expandBackward(parent *node, leaves *[]*node, i int) bool {
+	foundOne := false
+	step := &s.steps[i]
+	action := step.action
+
+	elapsed := parent.elapsed + action.Duration()
+	if s.Deadline > 0 && elapsed > s.Deadline {
+		return false
+	}
+
+	for _, candidate := range step.targets {
+		cost := parent.runningCost + s.cost(action, candidate.Target) + candidate.Cost
+		node := s.newNode(parent, cost, action)
+		step.regress(node.state, parent.state)
+		node.elapsed = elapsed
+		node.target = candidate.Target
+
+		if (condition{mask: node.state.known(), values: node.state.values()}).satisfiedBy(s.world) {
+			// the world state has everything the plan needs
+			*leaves = append(*leaves, node)
+			foundOne = true
+			continue
+		}
+		step.used = true
+		if s.buildBackward(node, leaves) {
+			foundOne = true
+		}
+		step.used = false
+	}
+	return foundOne
+}
+
+// regresses returns true if the action can be the last one before a state where the names in
+// needed must have the values. It must produce at least one of them, and neither its effects nor
+// its preconditions can go against the others.
+func (st *step) regresses(needed, values []uint64) bool {
+	produces := false
+	for w := range needed {
+		effects := st.effects.mask[w] & needed[w]
+		if effects&(st.effects.values[w]^values[w]) != 0 {
+			return false
+		}
+		if effects != 0 {
+			produces = true
+		}
+		untouched := needed[w] &^ st.effects.mask[w]
+		if untouched&st.preconditions.mask[w]&(st.preconditions.values[w]^values[w]) != 0 {
+			return false
+		}
+	}
+	return produces
+}
+
+// regress writes what is needed before the action into dst, given what is needed after it in src
+func (st *step) regress(dst, src bitState) {
+	dk, dv := dst.known(), dst.values()
+	sk, sv := src.known(), src.values()
+	for w := range dk {
+		untouched := sk[w] &^ st.effects.mask[w]
+		dk[w] = untouched | st.preconditions.mask[w]
+		dv[w] = sv[w]&untouched | st.preconditions.values[w]
+	}
+}
@@ -0,0 +1,96 @@
+package goap
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestPlan_BackwardSearch(t *testing.T) {
+	actions := []Action{findFood(), eatAction(), sleepAction()}
+
+	currentState := make(StateList)
+	currentState.Is(Hungry).Dont(HaveFood).Is(Tired)
+
+	goal := make(StateList)
+	goal.Isnt(Hungry)
+
+	planner := &Planner{Direction: BackwardSearch}
+	actionList := planner.Plan(&DefaultAgent{}, actions, currentState, goal)
+	if len(actionList) != 2 || actionList[0].String() != "getFood" || actionList[1].String() != "eat" {
+		t.Errorf("expected getFood and eat, got %v", actionList)
+	}
+}
+
+func TestPlan_BackwardSearch_precondition_conflict(t *testing.T) {
+	// eating leaves no food, so the food has to be fetched after eating
+	getFood := newTestAction("getFood", 1, false)
+	getFood.AddEffect(HaveFood)
+	eat := newTestAction("eat", 1, false)
+	eat.AddPrecondition(HaveFood)
+	eat.AddEffect(Isnt(Hungry), Dont(HaveFood))
+
+	currentState := make(StateList)
+	currentState.Is(Hungry).Is(HaveFood)
+
+	goal := make(StateList)
+	goal.Isnt(Hungry).Add(HaveFood)
+
+	planner := &Planner{Direction: BackwardSearch}
+	actionList := planner.Plan(&DefaultAgent{}, []Action{getFood, eat}, currentState, goal)
+	if len(actionList) != 2 || actionList[0].String() != "eat" {
+		t.Errorf("expected eat and then getFood, got %v", actionList)
+	}
+}
+
+func TestPlan_BackwardSearch_same_cost_as_forward(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	names := []string{"a", "b", "c", "d", "e", "f"}
+	randomState := func(n int) StateList {
+		s := make(StateList)
+		for i := 0; i < n; i++ {
+			s[names[random.Intn(len(names))]] = random.Intn(2) == 0
+		}
+		return s
+	}
+
+	found := 0
+	for run := 0; run < 200; run++ {
+		var actions []Action
+		for i := 0; i < 6; i++ {
+			a := newTestAction(fmt.Sprintf("action%d", i), float64(1+random.Intn(5)), false)
+			for name, value := range randomState(2) {
+				a.AddPrecondition(State{name, value})
+			}
+			for name, value := range randomState(2) {
+				a.AddEffect(State{name, value})
+			}
+			actions = append(actions, a)
+		}
+		currentState := randomState(4)
+		goal := randomState(2)
+		if inState(goal, currentState) {
+			continue
+		}
+
+		forward := Plan(&DefaultAgent{}, actions, currentState, goal)
+		backward := (&Planner{Direction: BackwardSearch}).Plan(&DefaultAgent{}, actions, currentState, goal)
+		if totalCost(forward) != totalCost(backward) || (forward == nil) != (backward == nil) {
+			t.Fatalf("expected the same cost from both directions, got %v and %v", forward, backward)
+		}
+		if forward != nil {
+			found++
+		}
+	}
+	if found == 0 {
+		t.Error("expected some of the domains to have a plan")
+	}
+}
+
+func totalCost(plan []Action) float64 {
+	cost := 0.0
+	for _, a := range plan {
+		cost += a.Cost()
+	}
+	return cost
+}
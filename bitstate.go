@@ -0,0 +1,150 @@
+package goap
+
+import (
+	"sync"
+)
+
+// stateIndex interns the state names used in one search, so that states can be kept as bitsets
+// where each name has its own bit.
+type stateIndex struct {
+	bits map[string]int
+	// the number of 64 bit words needed for one bit per name
+	words int
+}
+
+func newStateIndex() *stateIndex {
+	return &stateIndex{bits: make(map[string]int)}
+}
+
+// add gives the names in the state a bit each
+func (ix *stateIndex) add(state StateList) {
+	for name := range state {
+		if _, found := ix.bits[name]; !found {
+			ix.bits[name] = len(ix.bits)
+			ix.words = (len(ix.bits) + 63) / 64
+		}
+	}
+}
+
+// condition turns a precondition, effect or goal into masks
+func (ix *stateIndex) condition(state StateList) condition {
+	c := condition{
+		mask:   make([]uint64, ix.words),
+		values: make([]uint64, ix.words),
+	}
+	for name, value := range state {
+		bit := ix.bits[name]
+		c.mask[bit/64] |= 1 << (bit % 64)
+		if value {
+			c.values[bit/64] |= 1 << (bit % 64)
+		}
+	}
+	return c
+}
+
+// state turns a world state into a bitState
+func (ix *stateIndex) state(state StateList) bitState {
+	s := make(bitState, 2*ix.words)
+	ix.condition(state).apply(s, s)
+	return s
+}
+
+// bitState is a StateList as bitsets. The first half holds which names are set and the second half
+// their values.
+type bitState []uint64
+
+func (s bitState) known() []uint64 {
+	return s[:len(s)/2]
+}
+
+func (s bitState) values() []uint64 {
+	return s[len(s)/2:]
+}
+
+// condition is a StateList as bitsets, the names in it are set in mask and their values in values.
+type condition struct {
+	mask   []uint64
+	values []uint64
+}
+
+// satisfiedBy returns true if all names in the condition are set in the state to the same value, like
+// inState.
+func (c condition) satisfiedBy(s bitState) bool {
+	known, values := s.known(), s.values()
+	for i, mask := range c.mask {
+		if known[i]&mask != mask || values[i]&mask != c.values[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// apply the condition as an effect to the state src and write the result into dst, like
+// populateState. The states can be the same.
+func (c condition) apply(dst, src bitState) {
+	dk, dv := dst.known(), dst.values()
+	sk, sv := src.known(), src.values()
+	for i, mask := range c.mask {
+		dk[i] = sk[i] | mask
+		dv[i] = sv[i]&^mask | c.values[i]
+	}
+}
+
+// arenaChunk is how many nodes and states an arena allocates at a time
+const arenaChunk = 256
+
+// arena hands out the nodes and states of a search. They are allocated in chunks that are kept and
+// reused by later searches, so that expanding a node doesn't allocate.
+type arena struct {
+	nodes [][]node
+	used  int
+
+	words [][]uint64
+	// the chunk of words states are taken from and how much of it has been used
+	chunk  int
+	offset int
+}
+
+var arenas = sync.Pool{
+	New: func() interface{} { return &arena{} },
+}
+
+func newArena() *arena {
+	return arenas.Get().(*arena)
+}
+
+// release the nodes and states handed out so far and put the arena back in the pool
+func (a *arena) release() {
+	for i := 0; i < a.used; i++ {
+		a.nodes[i/arenaChunk][i%arenaChunk] = node{}
+	}
+	a.used = 0
+	a.chunk = 0
+	a.offset = 0
+	arenas.Put(a)
+}
+
+func (a *arena) node() *node {
+	if a.used == len(a.nodes)*arenaChunk {
+		a.nodes = append(a.nodes, make([]node, arenaChunk))
+	}
+	n := &a.nodes[a.used/arenaChunk][a.used%arenaChunk]
+	a.used++
+	return n
+}
+
+// state returns a state of size words, its content is undefined
+func (a *arena) state(size int) bitState {
+	for {
+		if a.chunk == len(a.words) {
+			a.words = append(a.words, make([]uint64, size*arenaChunk))
+		}
+		if c := a.words[a.chunk]; a.offset+size <= len(c) {
+			s := c[a.offset : a.offset+size : a.offset+size]
+			a.offset += size
+			return s
+		}
+		a.chunk++
+		a.offset = 0
+	}
+}
@@ -0,0 +1,69 @@
+package goap
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestCondition_like_inState(t *testing.T) {
+	state := StateList{"food": true, "hungry": false}
+	tests := []StateList{
+		{"food": true},
+		{"food": false},
+		{"hungry": false},
+		// names that aren't set don't count as false
+		{"tired": false},
+		{},
+	}
+
+	ix := newStateIndex()
+	ix.add(state)
+	for _, test := range tests {
+		ix.add(test)
+	}
+	s := ix.state(state)
+	for _, test := range tests {
+		if actual := ix.condition(test).satisfiedBy(s); actual != inState(test, state) {
+			t.Errorf("expected %v in %v to be %v, got %v", test, state, !actual, actual)
+		}
+	}
+}
+
+func TestCondition_apply_like_populateState(t *testing.T) {
+	state := StateList{"food": true, "hungry": true}
+	effects := StateList{"hungry": false, "tired": true}
+
+	ix := newStateIndex()
+	ix.add(state)
+	ix.add(effects)
+
+	s := ix.state(state)
+	ix.condition(effects).apply(s, s)
+
+	expected := populateState(state, effects)
+	if !ix.condition(expected).satisfiedBy(s) {
+		t.Errorf("expected the state to be %v", expected)
+	}
+	if ix.condition(StateList{"tired": false}).satisfiedBy(s) {
+		t.Error("expected tired to be set")
+	}
+}
+
+func TestPlan_many_state_names(t *testing.T) {
+	// more names than fit in one word
+	var actions []Action
+	for i := 0; i < 100; i++ {
+		a := newTestAction(fmt.Sprintf("step%d", i), 1, false)
+		a.AddPrecondition(State{fmt.Sprintf("done%d", i), true})
+		a.AddEffect(State{fmt.Sprintf("done%d", i+1), true})
+		actions = append(actions, a)
+	}
+
+	currentState := StateList{"done97": true}
+	goal := StateList{"done100": true}
+
+	actionList := Plan(&DefaultAgent{}, actions, currentState, goal)
+	if len(actionList) != 3 || actionList[0].String() != "step97" || actionList[2].String() != "step99" {
+		t.Errorf("expected step97 to step99, got %v", actionList)
+	}
+}
@@ -0,0 +1,246 @@
+package goap
+
+import (
+	"container/list"
+	"fmt"
+	"hash"
+	"hash/fnv"
+	"reflect"
+	"sort"
+	"sync"
+)
+
+// NewPlanCache creates a cache that holds the plans for at most size keys.
+func NewPlanCache(size int) *PlanCache {
+	return &PlanCache{
+		Size:    size,
+		entries: make(map[uint64]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// PlanCache remembers the plans found by a Planner, so that agents that share the same actions
+// don't search again for a start and goal that has already been planned for. The least recently
+// used plan is dropped when the cache is full. It's safe to use from several goroutines.
+//
+// Plans are keyed by a hash of the world state, the goal, the planner settings, including the
+// cooldown penalty, and the usable actions. The goal and the usable actions are compared on a hit as
+// well. CheckContextPrecondition is still called on every plan, and a plan is only reused when
+// the same actions pass it with the same costs and targets, or target candidates. Plans that
+// failed are cached as well.
+//
+// Changes that the key can't see, like changing the preconditions or effects of an action, must be
+// followed by InvalidateAction or Invalidate. Planners with a TravelCost don't use the cache, as
+// the cost depends on where the agent is.
+type PlanCache struct {
+	// How many plans the cache holds, zero means no limit.
+	Size int
+
+	mu      sync.Mutex
+	entries map[uint64]*list.Element
+	lru     *list.List
+	stats   CacheStats
+}
+
+// CacheStats counts how a PlanCache has been used.
+type CacheStats struct {
+	Hits      int
+	Misses    int
+	Evictions int
+}
+
+type cacheEntry struct {
+	key uint64
+	// the goal and the definitions of the usable actions the plan was found with, they are
+	// compared on a hit so that a hash collision can't return the plan of another goal
+	goal   StateList
+	usable []Action
+	// the plan as definitions and the targets they were planned with, nil if no plan was found
+	plan    []Action
+	targets []interface{}
+}
+
+// Len returns the number of cached plans.
+func (c *PlanCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// Stats returns the hits, misses and evictions so far.
+func (c *PlanCache) Stats() CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// Invalidate drops all cached plans.
+func (c *PlanCache) Invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[uint64]*list.Element)
+	c.lru.Init()
+}
+
+// InvalidateAction drops the cached plans that were planned with the action usable, it should be
+// called when the action's preconditions or effects change.
+func (c *PlanCache) InvalidateAction(action Action) {
+	definition := Definition(action)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for e := c.lru.Front(); e != nil; {
+		next := e.Next()
+		for _, a := range e.Value.(*cacheEntry).usable {
+			if a == definition {
+				c.remove(e)
+				break
+			}
+		}
+		e = next
+	}
+}
+
+// get returns the cached entry for the key if it matches, and marks it as recently used
+func (c *PlanCache) get(key uint64, matches func(*cacheEntry) bool) (*cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, found := c.entries[key]
+	if !found || !matches(e.Value.(*cacheEntry)) {
+		c.stats.Misses++
+		return nil, false
+	}
+	c.stats.Hits++
+	c.lru.MoveToFront(e)
+	return e.Value.(*cacheEntry), true
+}
+
+// put adds the entry, evicting the least recently used one if the cache is full
+func (c *PlanCache) put(entry *cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[uint64]*list.Element)
+		c.lru = list.New()
+	}
+	if e, found := c.entries[entry.key]; found {
+		e.Value = entry
+		c.lru.MoveToFront(e)
+		return
+	}
+	c.entries[entry.key] = c.lru.PushFront(entry)
+	for c.Size > 0 && c.lru.Len() > c.Size {
+		c.remove(c.lru.Back())
+		c.stats.Evictions++
+	}
+}
+
+func (c *PlanCache) remove(e *list.Element) {
+	delete(c.entries, e.Value.(*cacheEntry).key)
+	c.lru.Remove(e)
+}
+
+// cached returns the plan cached for the usable actions, with the usable instances in place of the
+// definitions. It returns false if there is no cached plan.
+func (s *search) cached(key uint64, usable []Action, goal StateList) ([]Action, bool) {
+	entry, found := s.Cache.get(key, func(entry *cacheEntry) bool {
+		return entry.matches(usable, goal)
+	})
+	if !found {
+		return nil, false
+	}
+	if entry.plan == nil {
+		return nil, true
+	}
+	instances := make(map[Action]Action, len(usable))
+	for _, action := range usable {
+		instances[Definition(action)] = action
+	}
+	result := make([]Action, len(entry.plan))
+	for i, definition := range entry.plan {
+		action := instances[definition]
+		if _, ok := action.(TargetProvider); ok {
+			action.SetTarget(entry.targets[i])
+		}
+		result[i] = action
+	}
+	return result, true
+}
+
+// matches returns true if the entry was planned for the goal with the same usable actions
+func (e *cacheEntry) matches(usable []Action, goal StateList) bool {
+	if len(e.usable) != len(usable) || len(e.goal) != len(goal) || !inState(goal, e.goal) {
+		return false
+	}
+	for i, action := range usable {
+		if e.usable[i] != Definition(action) {
+			return false
+		}
+	}
+	return true
+}
+
+// cache the plan found for the usable actions
+func (s *search) cache(key uint64, usable []Action, goal StateList, plan []Action) {
+	entry := &cacheEntry{key: key, goal: populateState(goal, nil), usable: make([]Action, len(usable))}
+	for i, action := range usable {
+		entry.usable[i] = Definition(action)
+	}
+	for _, action := range plan {
+		entry.plan = append(entry.plan, Definition(action))
+		entry.targets = append(entry.targets, action.Target())
+	}
+	s.Cache.put(entry)
+}
+
+// cacheKey hashes what the plan depends on. It returns false if the plan can't be cached, because
+// an action or a target can't be told apart from others.
+func (s *search) cacheKey(worldState StateList, goal StateList, usable []Action) (uint64, bool) {
+	h := fnv.New64a()
+	var penalty float64
+	if s.Cooldowns != nil {
+		penalty = s.Cooldowns.Penalty
+	}
+	fmt.Fprintf(h, "%d %d %g %g %g;", s.Objective, s.Direction, s.Deadline, s.TimeWeight, penalty)
+	writeState(h, worldState)
+	writeState(h, goal)
+	for _, action := range usable {
+		definition := Definition(action)
+		if reflect.ValueOf(definition).Kind() != reflect.Ptr {
+			return 0, false
+		}
+		fmt.Fprintf(h, "%p %g %g:", definition, action.Cost(), action.Duration())
+		for _, c := range s.targets[action] {
+			if c.Target != nil && !isComparable(c.Target) {
+				return 0, false
+			}
+			cooling := s.Cooldowns != nil && s.Cooldowns.Active(action, c.Target)
+			fmt.Fprintf(h, "%s %g %t,", targetKey(c.Target), c.Cost, cooling)
+		}
+		h.Write([]byte{';'})
+	}
+	return h.Sum64(), true
+}
+
+// writeState writes the state to the hash in a canonical order
+func writeState(h hash.Hash, state StateList) {
+	keys := make([]string, 0, len(state))
+	for key := range state {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(h, "%q=%t,", key, state[key])
+	}
+	h.Write([]byte{';'})
+}
+
+// targetKey tells targets apart, pointers by their address and other values by their content
+func targetKey(target interface{}) string {
+	if target == nil {
+		return "nil"
+	}
+	if reflect.ValueOf(target).Kind() == reflect.Ptr {
+		return fmt.Sprintf("%T(%p)", target, target)
+	}
+	return fmt.Sprintf("%T(%#v)", target, target)
+}
@@ -0,0 +1,191 @@
+package goap
+
+import (
+	"testing"
+)
+
+// switchAction can be made unusable by its context
+type switchAction struct {
+	DefaultAction
+	usable bool
+}
+
+func (a *switchAction) CheckContextPrecondition(agent Agent) bool {
+	return a.usable
+}
+
+func (a *switchAction) Perform(agent Agent) bool {
+	return true
+}
+
+func TestPlanCache(t *testing.T) {
+	fetch := newFetchAction("fetch", 2, Candidate{"far apple", 10}, Candidate{"near apple", 1})
+	fetch.AddEffect(HaveFood)
+	fetch.AddPrecondition(Dont(HaveFood))
+	actions := []Action{fetch, findFood(), eatAction()}
+
+	currentState := make(StateList)
+	currentState.Is(Hungry).Dont(HaveFood)
+
+	goal := make(StateList)
+	goal.Isnt(Hungry)
+
+	planner := &Planner{Cache: NewPlanCache(10)}
+	first := planner.Plan(&DefaultAgent{}, actions, currentState, goal)
+	second := planner.Plan(&DefaultAgent{}, actions, currentState, goal)
+
+	stats := planner.Cache.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("expected one miss and one hit, got %+v", stats)
+	}
+	if len(second) != 2 || second[0].String() != "fetch" || second[1].String() != "eat" {
+		t.Fatalf("expected the cached plan to be fetch and eat, got %v", second)
+	}
+	if second[0] == first[0] {
+		t.Error("expected the cached plan to be made of new instances")
+	}
+	if second[0].Target() != "near apple" {
+		t.Errorf("expected the cached target to be bound, got %v", second[0].Target())
+	}
+}
+
+func TestPlanCache_context_changed(t *testing.T) {
+	pray := &switchAction{DefaultAction: NewAction("prayForFood", 1), usable: true}
+	pray.AddEffect(HaveFood)
+	pray.AddPrecondition(Dont(HaveFood))
+	actions := []Action{pray, findFood(), eatAction()}
+
+	currentState := make(StateList)
+	currentState.Is(Hungry).Dont(HaveFood)
+
+	goal := make(StateList)
+	goal.Isnt(Hungry)
+
+	planner := &Planner{Cache: NewPlanCache(10)}
+	planner.Plan(&DefaultAgent{}, actions, currentState, goal)
+
+	pray.usable = false
+	actionList := planner.Plan(&DefaultAgent{}, actions, currentState, goal)
+	if len(actionList) != 2 || actionList[0].String() != "getFood" {
+		t.Errorf("expected a new plan without prayForFood, got %v", actionList)
+	}
+	if planner.Cache.Stats().Hits != 0 {
+		t.Errorf("expected no cache hits, got %+v", planner.Cache.Stats())
+	}
+}
+
+func TestPlanCache_eviction(t *testing.T) {
+	actions := []Action{findFood(), eatAction(), sleepAction()}
+
+	currentState := make(StateList)
+	currentState.Is(Hungry).Is(Tired).Dont(HaveFood)
+
+	fed := make(StateList)
+	fed.Isnt(Hungry)
+	rested := make(StateList)
+	rested.Isnt(Tired)
+
+	planner := &Planner{Cache: NewPlanCache(1)}
+	planner.Plan(&DefaultAgent{}, actions, currentState, fed)
+	planner.Plan(&DefaultAgent{}, actions, currentState, rested)
+	planner.Plan(&DefaultAgent{}, actions, currentState, fed)
+
+	stats := planner.Cache.Stats()
+	if stats.Misses != 3 || stats.Evictions != 2 {
+		t.Errorf("expected every plan to miss and evict the one before, got %+v", stats)
+	}
+	if planner.Cache.Len() != 1 {
+		t.Errorf("expected the cache to hold one plan, got %d", planner.Cache.Len())
+	}
+}
+
+func TestPlanCache_InvalidateAction(t *testing.T) {
+	getFood := findFood()
+	sleep := sleepAction()
+	actions := []Action{getFood, eatAction(), sleep}
+
+	currentState := make(StateList)
+	currentState.Is(Hungry).Dont(HaveFood)
+
+	goal := make(StateList)
+	goal.Isnt(Hungry)
+
+	planner := &Planner{Cache: NewPlanCache(10)}
+	planner.Plan(&DefaultAgent{}, actions, currentState, goal)
+
+	// sleep isn't in the plan, but the plan was found with it
+	planner.Cache.InvalidateAction(sleep)
+	if planner.Cache.Len() != 0 {
+		t.Errorf("expected the plan to be dropped, got %d plans", planner.Cache.Len())
+	}
+
+	planner.Plan(&DefaultAgent{}, actions, currentState, goal)
+	getFood.AddPrecondition(Tired)
+	planner.Cache.InvalidateAction(getFood)
+	if actionList := planner.Plan(&DefaultAgent{}, actions, currentState, goal); actionList != nil {
+		t.Errorf("expected getFood to need the agent to be tired, got %v", actionList)
+	}
+}
+
+func TestPlanCache_collision(t *testing.T) {
+	actions := []Action{findFood(), eatAction(), sleepAction()}
+
+	currentState := make(StateList)
+	currentState.Is(Hungry).Is(Tired).Dont(HaveFood)
+
+	notHungry := make(StateList)
+	notHungry.Isnt(Hungry)
+	rested := make(StateList)
+	rested.Isnt(Tired)
+
+	planner := &Planner{Cache: NewPlanCache(10)}
+	planner.Plan(&DefaultAgent{}, actions, currentState, notHungry)
+	planner.Plan(&DefaultAgent{}, actions, currentState, rested)
+
+	// point the key of rested at the plan for notHungry, like a hash collision would
+	var hungry *cacheEntry
+	for _, e := range planner.Cache.entries {
+		if entry := e.Value.(*cacheEntry); len(entry.plan) == 2 {
+			hungry = entry
+		}
+	}
+	for key, e := range planner.Cache.entries {
+		if e.Value.(*cacheEntry) != hungry {
+			collided := *hungry
+			collided.key = key
+			e.Value = &collided
+		}
+	}
+
+	actionList := planner.Plan(&DefaultAgent{}, actions, currentState, rested)
+	if len(actionList) != 1 || actionList[0].String() != "sleep" {
+		t.Errorf("expected the plan for another goal not to be used, got %v", actionList)
+	}
+}
+
+func TestPlanCache_cooldown_penalty(t *testing.T) {
+	steal := newTestAction("steal", 1, false)
+	steal.AddEffect(HaveFood)
+	steal.AddPrecondition(Dont(HaveFood))
+	actions := []Action{steal, findFood(), eatAction()}
+
+	currentState := make(StateList)
+	currentState.Is(Hungry).Dont(HaveFood)
+
+	goal := make(StateList)
+	goal.Isnt(Hungry)
+
+	planner := &Planner{Cache: NewPlanCache(10), Cooldowns: NewCooldowns(0)}
+	planner.Cooldowns.Penalty = 1
+	planner.Cooldowns.Start(steal, nil, 10)
+	actionList := planner.Plan(&DefaultAgent{}, actions, currentState, goal)
+	if len(actionList) != 2 || Definition(actionList[0]) != steal {
+		t.Fatalf("expected steal to be worth the penalty, got %v", actionList)
+	}
+
+	planner.Cooldowns.Penalty = 100
+	actionList = planner.Plan(&DefaultAgent{}, actions, currentState, goal)
+	if len(actionList) != 2 || actionList[0].String() != "getFood" {
+		t.Errorf("expected a new plan when the penalty changes, got %v", actionList)
+	}
+}
@@ -0,0 +1,163 @@
+// Command benchcmp compares two outputs of go test -bench, for example from before and after a
+// change to the planner:
+//
+//	go test -run NONE -bench . -count 5 > old.txt
+//	go test -run NONE -bench . -count 5 > new.txt
+//	benchcmp -threshold 10 old.txt new.txt
+//
+// It prints every metric of every benchmark found in both, like ns/op, allocs/op and nodes/op, with
+// the change in percent. Benchmarks that are run more than once are averaged. It exits with status
+// 1 if a metric got worse by more than the threshold.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+func main() {
+	threshold := flag.Float64("threshold", 10, "the change in percent that counts as a regression")
+	flag.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: benchcmp [-threshold percent] old.txt new.txt")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	before, err := parseFile(flag.Arg(0))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	after, err := parseFile(flag.Arg(1))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	rows := compare(before, after, *threshold)
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "benchmark\tmetric\told\tnew\tdelta\t")
+	regressions := 0
+	for _, r := range rows {
+		mark := ""
+		if r.regression {
+			mark = "regression"
+			regressions++
+		}
+		fmt.Fprintf(w, "%s\t%s\t%.4g\t%.4g\t%+.2f%%\t%s\n", r.name, r.unit, r.before, r.after, r.delta, mark)
+	}
+	w.Flush()
+	if regressions > 0 {
+		fmt.Fprintf(os.Stderr, "%d metrics got worse by more than %g%%\n", regressions, *threshold)
+		os.Exit(1)
+	}
+}
+
+// results holds the averaged metrics of each benchmark, in the order they were first seen.
+type results struct {
+	names   []string
+	metrics map[string]map[string]float64
+	units   map[string][]string
+}
+
+func parseFile(path string) (*results, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parse(f)
+}
+
+// parse reads the output of go test -bench, lines that aren't benchmark results are skipped
+func parse(r io.Reader) (*results, error) {
+	res := &results{
+		metrics: make(map[string]map[string]float64),
+		units:   make(map[string][]string),
+	}
+	counts := make(map[string]map[string]int)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		// a name, the iterations and at least one value and unit
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") || len(fields)%2 != 0 {
+			continue
+		}
+		if _, err := strconv.Atoi(fields[1]); err != nil {
+			continue
+		}
+		name := fields[0]
+		if _, found := res.metrics[name]; !found {
+			res.names = append(res.names, name)
+			res.metrics[name] = make(map[string]float64)
+			counts[name] = make(map[string]int)
+		}
+		for i := 2; i < len(fields); i += 2 {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s: bad value %q for %s", name, fields[i], fields[i+1])
+			}
+			unit := fields[i+1]
+			if counts[name][unit] == 0 {
+				res.units[name] = append(res.units[name], unit)
+			}
+			// keep a running average over repeated runs
+			counts[name][unit]++
+			res.metrics[name][unit] += (value - res.metrics[name][unit]) / float64(counts[name][unit])
+		}
+	}
+	return res, scanner.Err()
+}
+
+type row struct {
+	name, unit string
+	before     float64
+	after      float64
+	// the change in percent
+	delta      float64
+	regression bool
+}
+
+// compare the metrics of the benchmarks found in both results
+func compare(before, after *results, threshold float64) []row {
+	var rows []row
+	for _, name := range before.names {
+		if _, found := after.metrics[name]; !found {
+			continue
+		}
+		for _, unit := range before.units[name] {
+			a, found := after.metrics[name][unit]
+			if !found {
+				continue
+			}
+			b := before.metrics[name][unit]
+			r := row{name: name, unit: unit, before: b, after: a}
+			if b != 0 {
+				r.delta = (a - b) / b * 100
+			}
+			worse := r.delta
+			if higherIsBetter(unit) {
+				worse = -worse
+			}
+			r.regression = worse > threshold
+			rows = append(rows, r)
+		}
+	}
+	return rows
+}
+
+// higherIsBetter returns true for throughput units like MB/s, for all others lower is better
+func higherIsBetter(unit string) bool {
+	return strings.HasSuffix(unit, "/s")
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+const oldOutput = `goos: linux
+goarch: amd64
+pkg: github.com/stojg/goap
+BenchmarkPlan/actions=5-8     	  60000	     20000 ns/op	         5.000 nodes/op	    3952 B/op	      97 allocs/op
+BenchmarkPlan/actions=5-8     	  60000	     22000 ns/op	         5.000 nodes/op	    3952 B/op	      97 allocs/op
+BenchmarkPlan/actions=50-8    	   3000	    400000 ns/op	      2046 nodes/op	   66330 B/op	     685 allocs/op
+PASS
+ok  	github.com/stojg/goap	3.304s
+`
+
+const newOutput = `BenchmarkPlan/actions=5-8     	  60000	     21000 ns/op	         5.000 nodes/op	    3952 B/op	      97 allocs/op
+BenchmarkPlan/actions=50-8    	   3000	    500000 ns/op	      2046 nodes/op	   66330 B/op	     600 allocs/op
+BenchmarkPlan/actions=99-8    	   3000	    900000 ns/op
+`
+
+func TestParse(t *testing.T) {
+	res, err := parse(strings.NewReader(oldOutput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.names) != 2 {
+		t.Fatalf("expected two benchmarks, got %v", res.names)
+	}
+	if ns := res.metrics["BenchmarkPlan/actions=5-8"]["ns/op"]; ns != 21000 {
+		t.Errorf("expected repeated runs to be averaged to 21000 ns/op, got %g", ns)
+	}
+	if units := res.units["BenchmarkPlan/actions=50-8"]; len(units) != 4 || units[1] != "nodes/op" {
+		t.Errorf("expected the units in order, got %v", units)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	before, _ := parse(strings.NewReader(oldOutput))
+	after, _ := parse(strings.NewReader(newOutput))
+
+	rows := compare(before, after, 10)
+	if len(rows) != 8 {
+		t.Fatalf("expected the metrics of the two shared benchmarks, got %d rows", len(rows))
+	}
+	var regressions []string
+	for _, r := range rows {
+		if r.regression {
+			regressions = append(regressions, r.name+" "+r.unit)
+		}
+	}
+	if len(regressions) != 1 || regressions[0] != "BenchmarkPlan/actions=50-8 ns/op" {
+		t.Errorf("expected only the slower ns/op to be a regression, got %v", regressions)
+	}
+}
+
+func TestHigherIsBetter(t *testing.T) {
+	if !higherIsBetter("MB/s") || higherIsBetter("ns/op") {
+		t.Error("expected only throughput to be better when higher")
+	}
+}
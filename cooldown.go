@@ -0,0 +1,126 @@
+package goap
+
+import (
+	"reflect"
+	"sync"
+)
+
+// NewCooldowns creates an empty failure memory where actions that abort a plan are kept out of
+// planning for failureCooldown updates.
+func NewCooldowns(failureCooldown int) *Cooldowns {
+	return &Cooldowns{
+		FailureCooldown: failureCooldown,
+		until:           make(map[cooldownKey]int),
+	}
+}
+
+// Cooldowns remembers actions that recently failed or were used. The planner either leaves these
+// actions out of new plans, or makes them more expensive, until their cooldown has run out.
+// Instances of an action share the cooldowns of their definition.
+//
+// Time is counted in updates. An FSM without a Scheduler ticks the Cooldowns of its Planner on
+// every update, so agents that aren't managed shouldn't share them. An AgentManager ticks the
+// Cooldowns of the agents it manages once per tick, however many of them share it. Cooldowns are
+// safe to use from several goroutines.
+type Cooldowns struct {
+	// How many updates an action that failed is kept out of planning.
+	FailureCooldown int
+	// If larger than zero, actions that are cooling down are not excluded from planning, instead
+	// this is added to their cost.
+	Penalty float64
+	// Remember failures per action and target instead of per action, so that the same action can
+	// still be planned against another target.
+	PerTarget bool
+
+	mu    sync.Mutex
+	now   int
+	until map[cooldownKey]int
+}
+
+type cooldownKey struct {
+	action Action
+	target interface{}
+}
+
+// Tick advances the cooldowns one update and forgets the ones that have run out.
+func (c *Cooldowns) Tick() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now++
+	for key, until := range c.until {
+		if until <= c.now {
+			delete(c.until, key)
+		}
+	}
+}
+
+// Start puts the action, or the action and target pair if target isn't nil, on cooldown for a
+// number of updates. An existing longer cooldown is kept.
+func (c *Cooldowns) Start(action Action, target interface{}, updates int) {
+	if updates <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.until == nil {
+		c.until = make(map[cooldownKey]int)
+	}
+	key := newCooldownKey(action, target)
+	if c.until[key] < c.now+updates {
+		c.until[key] = c.now + updates
+	}
+}
+
+// Failed records that the action failed and puts it on cooldown for FailureCooldown updates.
+func (c *Cooldowns) Failed(action Action) {
+	var target interface{}
+	if c.PerTarget {
+		target = action.Target()
+	}
+	c.Start(action, target, c.FailureCooldown)
+}
+
+// Used records that the action is done and starts its own Action.Cooldown.
+func (c *Cooldowns) Used(action Action) {
+	c.Start(action, nil, action.Cooldown())
+}
+
+// Remaining returns how many updates are left until the action can be used against the target.
+func (c *Cooldowns) Remaining(action Action, target interface{}) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	until := c.until[newCooldownKey(action, nil)]
+	if target != nil {
+		if t := c.until[newCooldownKey(action, target)]; t > until {
+			until = t
+		}
+	}
+	if until <= c.now {
+		return 0
+	}
+	return until - c.now
+}
+
+// Active returns true if the action, or the action against the target, is cooling down.
+func (c *Cooldowns) Active(action Action, target interface{}) bool {
+	return c.Remaining(action, target) > 0
+}
+
+// Clear forgets all cooldowns.
+func (c *Cooldowns) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.until = make(map[cooldownKey]int)
+}
+
+// newCooldownKey drops targets that can't be used as map keys, they fall back to the action alone.
+func newCooldownKey(action Action, target interface{}) cooldownKey {
+	if !isComparable(target) {
+		target = nil
+	}
+	return cooldownKey{action: Definition(action), target: target}
+}
+
+func isComparable(v interface{}) bool {
+	return v != nil && reflect.TypeOf(v).Comparable()
+}
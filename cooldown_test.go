@@ -0,0 +1,130 @@
+package goap
+
+import (
+	"testing"
+)
+
+func TestCooldowns(t *testing.T) {
+	eat := eatAction()
+	cooldowns := NewCooldowns(2)
+
+	cooldowns.Failed(eat)
+	if !cooldowns.Active(eat, nil) {
+		t.Error("expected eat to be cooling down after it failed")
+	}
+
+	cooldowns.Tick()
+	if cooldowns.Remaining(eat, nil) != 1 {
+		t.Errorf("expected 1 update left, got %d", cooldowns.Remaining(eat, nil))
+	}
+
+	cooldowns.Tick()
+	if cooldowns.Active(eat, nil) {
+		t.Error("expected eat cooldown to have run out")
+	}
+}
+
+func TestCooldowns_PerTarget(t *testing.T) {
+	eat := eatAction()
+	eat.SetTarget("apple")
+	cooldowns := NewCooldowns(5)
+	cooldowns.PerTarget = true
+
+	cooldowns.Failed(eat)
+
+	if !cooldowns.Active(eat, "apple") {
+		t.Error("expected eat to be cooling down for the apple")
+	}
+	if cooldowns.Active(eat, "pear") {
+		t.Error("expected eat to be usable for the pear")
+	}
+}
+
+func TestCooldowns_Used(t *testing.T) {
+	sleep := sleepAction()
+	sleep.SetCooldown(3)
+	cooldowns := NewCooldowns(0)
+
+	cooldowns.Used(sleep)
+
+	if cooldowns.Remaining(sleep, nil) != 3 {
+		t.Errorf("expected sleep to cool down for 3 updates, got %d", cooldowns.Remaining(sleep, nil))
+	}
+}
+
+func TestPlanner_Cooldowns(t *testing.T) {
+	prayForFood := newTestAction("prayForFood", 6, false)
+	prayForFood.AddEffect(HaveFood)
+	prayForFood.AddPrecondition(Dont(HaveFood))
+
+	actions := []Action{findFood(), prayForFood, eatAction()}
+
+	currentState := make(StateList)
+	currentState.Is(Hungry).Dont(HaveFood)
+
+	goal := make(StateList)
+	goal.Isnt(Hungry)
+
+	planner := &Planner{Cooldowns: NewCooldowns(10)}
+	planner.Cooldowns.Failed(prayForFood)
+
+	actionList := planner.Plan(&DefaultAgent{}, actions, currentState, goal)
+	if len(actionList) != 2 || actionList[0].String() != "getFood" {
+		t.Errorf("expected the failed prayForFood to be excluded, got %v", actionList)
+	}
+
+	// a penalty smaller than the cost difference should keep the cheaper action
+	planner.Cooldowns.Penalty = 1
+	actionList = planner.Plan(&DefaultAgent{}, actions, currentState, goal)
+	if len(actionList) != 2 || actionList[0].String() != "prayForFood" {
+		t.Errorf("expected the penalised prayForFood to still be cheapest, got %v", actionList)
+	}
+}
+
+func TestDo_cooldown_after_abort(t *testing.T) {
+	flaky := newFlakyAction("flaky", 1, 5)
+	flaky.AddEffect(HaveFood)
+
+	slow := newFlakyAction("slow", 10, 0)
+	slow.AddEffect(HaveFood)
+
+	agent := newRecordingAgent(flaky, slow)
+	agent.SetGoalState(StateList{HaveFood.Name: true})
+	agent.StateMachine.Planner = &Planner{Cooldowns: NewCooldowns(100)}
+
+	// plan flaky, flaky aborts, plan again and perform slow
+	for i := 0; i < 4; i++ {
+		agent.Update()
+	}
+
+	if *flaky.performs != 1 {
+		t.Errorf("expected flaky to only be tried once, got %d", *flaky.performs)
+	}
+	if *slow.performs != 1 {
+		t.Errorf("expected slow to be planned after flaky failed, got %d performs", *slow.performs)
+	}
+}
+
+func TestDo_cooldown_expires(t *testing.T) {
+	flaky := newFlakyAction("flaky", 1, 1)
+	flaky.AddEffect(HaveFood)
+
+	agent := newRecordingAgent(flaky)
+	agent.SetGoalState(StateList{HaveFood.Name: true})
+	agent.StateMachine.Planner = &Planner{Cooldowns: NewCooldowns(3)}
+
+	// plan flaky, flaky aborts and cools down
+	agent.Update()
+	agent.Update()
+	if len(agent.aborted) != 1 {
+		t.Fatalf("expected flaky to abort the plan, got %v", agent.aborted)
+	}
+
+	// without a manager the FSM ticks the cooldowns, so flaky is planned and performed again
+	for i := 0; i < 5; i++ {
+		agent.Update()
+	}
+	if *flaky.performs != 2 {
+		t.Errorf("expected flaky to be tried again after its cooldown, got %d performs", *flaky.performs)
+	}
+}
@@ -0,0 +1,221 @@
+package goap
+
+// Coordinator plans for a group of agents that work towards a shared goal, so that they don't get
+// in each other's way.
+type Coordinator struct {
+	Agents []Agent
+
+	// Planner settings used for the group, Plan is used if this is nil. TravelCost isn't used, since
+	// every agent travels from a different place. If it has Reservations, the targets of the plan are
+	// reserved for the agents they are assigned to.
+	Planner *Planner
+}
+
+// NewCoordinator creates a coordinator for the agents.
+func NewCoordinator(agents ...Agent) *Coordinator {
+	return &Coordinator{Agents: agents}
+}
+
+// Plan finds the cheapest plan that reaches the goal using the actions of all agents, starting from
+// a world state that is shared by the whole group. The actions are assigned to the agent they came
+// from, and two agents are never planned to use the same target or the same action instance.
+//
+// Each agent gets its own part of the plan with SetCurrentActions and PlanFound, and agents that
+// aren't needed get an empty plan. If no plan is found, or its targets can't be reserved, PlanFailed
+// is called on all agents and nil is returned. The agents' FSMs should use Coordinated instead of
+// Idle, so that they perform the plans they are given instead of planning by themselves.
+func (c *Coordinator) Plan(worldState StateList, goal StateList) []*Assignment {
+	var actions []Action
+	for _, agent := range c.Agents {
+		for _, action := range agent.AvailableActions() {
+			actions = append(actions, &Assignment{Action: action, Agent: agent})
+		}
+	}
+
+	p := Planner{}
+	if c.Planner != nil {
+		p = *c.Planner
+	}
+	p.TravelCost = nil
+	s := p.newSearch(nil)
+	s.conflicts = conflictingAssignment
+	plan := s.plan(actions, worldState, goal)
+	if p.Stats != nil {
+		p.Stats.add(1, int64(s.nodes))
+	}
+	if plan == nil || !reserveAssignments(p.Reservations, plan) {
+		for _, agent := range c.Agents {
+			agent.PlanFailed(goal)
+		}
+		return nil
+	}
+
+	// actions that depend on other agents wait for them to be done
+	partial := NewPartialPlan(plan)
+	assignments := make([]*Assignment, len(plan))
+	for i, action := range plan {
+		assignments[i] = action.(*Assignment)
+		for _, j := range partial.After[i] {
+			if other := plan[j].(*Assignment); other.Agent != assignments[i].Agent {
+				assignments[i].after = append(assignments[i].after, other)
+			}
+		}
+	}
+
+	for _, agent := range c.Agents {
+		var own []Action
+		for _, a := range assignments {
+			if a.Agent == agent {
+				own = append(own, a)
+			}
+		}
+		agent.SetCurrentActions(own)
+		agent.PlanFound(goal, own)
+	}
+	return assignments
+}
+
+// reserveAssignments reserves the targets of the plan for the agents they are assigned to. If a
+// target has been reserved by someone else in the meantime, the reservations it made are released
+// again and false is returned.
+func reserveAssignments(r *Reservations, plan []Action) bool {
+	if r == nil {
+		return true
+	}
+	var made []*Assignment
+	for _, action := range plan {
+		a := action.(*Assignment)
+		if a.Target() == nil {
+			continue
+		}
+		if owner, found := r.Owner(a.Target()); found && owner == a.Agent {
+			// already held from an earlier plan, only renew it
+			r.Reserve(a.Target(), a.Agent)
+			continue
+		}
+		if !r.Reserve(a.Target(), a.Agent) {
+			for _, m := range made {
+				r.Release(m.Target(), m.Agent)
+			}
+			return false
+		}
+		made = append(made, a)
+	}
+	return true
+}
+
+// Coordinated is an FSM state for agents that get their plans from a Coordinator, it's used in place
+// of Idle with NewFSM(Coordinated). It waits until the agent has actions and then performs them.
+func Coordinated(fsm *FSM, agent Agent, debug func(string)) {
+	fsm.idle = Coordinated
+	if len(agent.CurrentActions()) == 0 {
+		return
+	}
+	debug("Coordinated - got a plan")
+	fsm.Reset(Do)
+}
+
+// abandonAssignments marks the assignments in an aborted plan, so that the agents waiting for them
+// fail instead of waiting forever
+func abandonAssignments(actions []Action) {
+	for _, action := range actions {
+		if a, ok := action.(*Assignment); ok {
+			a.abandoned = true
+		}
+	}
+}
+
+// conflictingAssignment returns true if the action can't be added after parent, because its action
+// or target is already used by another agent
+func conflictingAssignment(parent *node, action Action, target interface{}) bool {
+	a := action.(*Assignment)
+	for n := parent; n != nil; n = n.parent {
+		b, ok := n.action.(*Assignment)
+		if !ok {
+			continue
+		}
+		if Definition(b.Action) == Definition(a.Action) {
+			return true
+		}
+		if b.Agent != a.Agent && isComparable(target) && n.target == target {
+			return true
+		}
+	}
+	return false
+}
+
+// Assignment is an action of a coordinated plan together with the agent that performs it. It
+// behaves like the action, but is always checked and performed for its own agent, and doesn't start
+// until the actions of other agents that it depends on are done.
+type Assignment struct {
+	Action
+	Agent Agent
+
+	// actions of other agents that must be done first
+	after []*Assignment
+	// the plan of the agent was aborted before the action was done
+	abandoned bool
+}
+
+// Instance makes an instance of the action for the same agent.
+func (a *Assignment) Instance() Action {
+	return &Assignment{Action: NewInstance(a.Action), Agent: a.Agent}
+}
+
+// the definition of an assignment is the definition of its action, so that cooldowns recorded for
+// an assignment apply to the action
+func (a *Assignment) setDefinition(Action) {}
+
+func (a *Assignment) definitionOf() Action {
+	return Definition(a.Action)
+}
+
+func (a *Assignment) defaultAction() *DefaultAction {
+	if i, ok := a.Action.(instance); ok {
+		return i.defaultAction()
+	}
+	return nil
+}
+
+// Waiting returns true while actions of other agents that this one depends on aren't done.
+func (a *Assignment) Waiting() bool {
+	for _, other := range a.after {
+		if !other.IsDone() {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Assignment) CheckContextPrecondition(agent Agent) bool {
+	return a.Action.CheckContextPrecondition(a.Agent)
+}
+
+func (a *Assignment) InRange(agent Agent) bool {
+	return a.Action.InRange(a.Agent)
+}
+
+// Perform the action, or do nothing and wait while it's Waiting. It fails if the plan of an agent
+// that it waits for was aborted, as the action it waits for will never be done.
+func (a *Assignment) Perform(agent Agent) bool {
+	for _, other := range a.after {
+		if other.abandoned {
+			return false
+		}
+	}
+	if a.Waiting() {
+		return true
+	}
+	return a.Action.Perform(a.Agent)
+}
+
+// Targets makes the planner choose a target for the agent, see TargetProvider.
+func (a *Assignment) Targets(agent Agent) []Candidate {
+	if provider, ok := a.Action.(TargetProvider); ok {
+		return provider.Targets(a.Agent)
+	}
+	if !a.RequiresInRange() || a.Target() != nil || a.Action.InRange(a.Agent) {
+		return []Candidate{{Target: a.Target()}}
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package goap
+
+import (
+	"testing"
+)
+
+func TestCoordinator_conflicting_targets(t *testing.T) {
+	medkits := []Candidate{{Target: "medkit1", Cost: 1}, {Target: "medkit2", Cost: 5}}
+	healA := newFetchAction("heal", 1, medkits...)
+	healA.AddEffect(State{"healed(a)", true})
+	healB := newFetchAction("heal", 1, medkits...)
+	healB.AddEffect(State{"healed(b)", true})
+
+	a := newRecordingAgent(healA)
+	b := newRecordingAgent(healB)
+
+	goal := StateList{"healed(a)": true, "healed(b)": true}
+	assignments := NewCoordinator(a, b).Plan(make(StateList), goal)
+	if len(assignments) != 2 {
+		t.Fatalf("expected two assignments, got %v", assignments)
+	}
+	if assignments[0].Target() == assignments[1].Target() {
+		t.Errorf("expected the agents to use different medkits, both got %v", assignments[0].Target())
+	}
+	if len(a.CurrentActions()) != 1 || len(b.CurrentActions()) != 1 {
+		t.Errorf("expected each agent to get one action, got %v and %v", a.CurrentActions(), b.CurrentActions())
+	}
+}
+
+func TestCoordinator_shared_action(t *testing.T) {
+	// both agents can use the same action instance, but it can only be planned once
+	heal := newFetchAction("heal", 1, Candidate{Target: "medkit"})
+	heal.AddEffect(State{"healed", true})
+
+	a := newRecordingAgent(heal)
+	b := newRecordingAgent(heal)
+
+	goal := StateList{"healed": true}
+	assignments := NewCoordinator(a, b).Plan(make(StateList), goal)
+	if len(assignments) != 1 {
+		t.Errorf("expected the shared action to be assigned once, got %v", assignments)
+	}
+}
+
+func TestCoordinator_dependencies(t *testing.T) {
+	openDoor := newFlakyAction("openDoor", 1, 0)
+	openDoor.AddEffect(State{"doorOpen", true})
+	goThrough := newFlakyAction("goThrough", 1, 0)
+	goThrough.AddPrecondition(State{"doorOpen", true})
+	goThrough.AddEffect(State{"through", true})
+
+	a := newRecordingAgent(openDoor)
+	a.StateMachine = NewFSM(Coordinated)
+	b := newRecordingAgent(goThrough)
+	b.StateMachine = NewFSM(Coordinated)
+
+	if NewCoordinator(a, b).Plan(make(StateList), StateList{"through": true}) == nil {
+		t.Fatal("expected a plan")
+	}
+
+	// b waits for a to open the door
+	b.Update()
+	b.Update()
+	if *goThrough.performs != 0 {
+		t.Error("expected goThrough to wait for the door to be opened")
+	}
+
+	a.Update()
+	a.Update()
+	b.Update()
+	if *openDoor.performs != 1 || *goThrough.performs != 1 {
+		t.Errorf("expected both actions to be performed once, got %d and %d", *openDoor.performs, *goThrough.performs)
+	}
+}
+
+func TestCoordinator_dependency_aborted(t *testing.T) {
+	openDoor := newFlakyAction("openDoor", 1, 1)
+	openDoor.AddEffect(State{"doorOpen", true})
+	goThrough := newFlakyAction("goThrough", 1, 0)
+	goThrough.AddPrecondition(State{"doorOpen", true})
+	goThrough.AddEffect(State{"through", true})
+
+	a := newRecordingAgent(openDoor)
+	a.StateMachine = NewFSM(Coordinated)
+	b := newRecordingAgent(goThrough)
+	b.StateMachine = NewFSM(Coordinated)
+
+	if NewCoordinator(a, b).Plan(make(StateList), StateList{"through": true}) == nil {
+		t.Fatal("expected a plan")
+	}
+
+	// a fails to open the door, so b can't go through
+	a.Update()
+	a.Update()
+	if len(a.aborted) != 1 {
+		t.Fatalf("expected openDoor to abort the plan of a, got %v", a.aborted)
+	}
+	b.Update()
+	b.Update()
+	if len(b.aborted) != 1 || *goThrough.performs != 0 {
+		t.Errorf("expected the plan of b to be aborted without going through, got %v", b.aborted)
+	}
+}
+
+func TestCoordinator_cooldowns(t *testing.T) {
+	heal := newTestAction("heal", 1, false)
+	heal.AddEffect(State{"healed", true})
+	bandage := newTestAction("bandage", 5, false)
+	bandage.AddEffect(State{"healed", true})
+
+	a := newRecordingAgent(heal, bandage)
+	c := NewCoordinator(a)
+	c.Planner = &Planner{Cooldowns: NewCooldowns(10)}
+
+	goal := StateList{"healed": true}
+	first := c.Plan(make(StateList), goal)
+	if len(first) != 1 || Definition(first[0]) != heal {
+		t.Fatalf("expected heal to be assigned, got %v", first)
+	}
+
+	c.Planner.Cooldowns.Failed(first[0])
+	if !c.Planner.Cooldowns.Active(heal, nil) {
+		t.Error("expected the cooldown of the assignment to apply to its action")
+	}
+	second := c.Plan(make(StateList), goal)
+	if len(second) != 1 || Definition(second[0]) != bandage {
+		t.Errorf("expected bandage to be assigned while heal cools down, got %v", second)
+	}
+}
+
+func TestCoordinator_failed(t *testing.T) {
+	a := newRecordingAgent(findFood())
+	if NewCoordinator(a).Plan(make(StateList), StateList{"isWarm": true}) != nil {
+		t.Error("expected no plan")
+	}
+}
+
+func TestCoordinator_replan_with_reservations(t *testing.T) {
+	medkits := []Candidate{{Target: "medkit1", Cost: 1}, {Target: "medkit2", Cost: 5}}
+	healA := newFetchAction("heal", 1, medkits...)
+	healA.AddEffect(State{"healed(a)", true})
+	healB := newFetchAction("heal", 1, medkits...)
+	healB.AddEffect(State{"healed(b)", true})
+
+	a := newRecordingAgent(healA)
+	b := newRecordingAgent(healB)
+	c := NewCoordinator(a, b)
+	c.Planner = &Planner{Reservations: NewReservations(0)}
+
+	goal := StateList{"healed(a)": true, "healed(b)": true}
+	first := c.Plan(make(StateList), goal)
+	if len(first) != 2 {
+		t.Fatalf("expected two assignments, got %v", first)
+	}
+	// the group's own reservations don't stand in the way of planning again
+	second := c.Plan(make(StateList), goal)
+	if len(second) != 2 {
+		t.Fatalf("expected to plan again with the targets reserved by the group, got %v", second)
+	}
+	for i := range second {
+		if owner, _ := c.Planner.Reservations.Owner(second[i].Target()); owner != second[i].Agent {
+			t.Errorf("expected %v to be reserved by its agent", second[i].Target())
+		}
+	}
+}
+
+func TestCoordinator_reserve_rollback(t *testing.T) {
+	a := newRecordingAgent()
+	outsider := &DefaultAgent{}
+	r := NewReservations(0)
+	r.Reserve("medkit2", outsider)
+
+	first := &Assignment{Action: newTestAction("heal", 1, false), Agent: a}
+	first.SetTarget("medkit1")
+	second := &Assignment{Action: newTestAction("heal", 1, false), Agent: a}
+	second.SetTarget("medkit2")
+
+	if reserveAssignments(r, []Action{first, second}) {
+		t.Fatal("expected the reservation to fail")
+	}
+	if _, found := r.Owner("medkit1"); found {
+		t.Error("expected medkit1 to be released again")
+	}
+}
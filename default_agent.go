@@ -75,6 +75,11 @@ func (p *DefaultAgent) MoveAgent(nextAction Action) bool {
 	return false
 }
 
+// Machine returns the agent's state machine.
+func (a *DefaultAgent) Machine() *FSM {
+	return a.StateMachine
+}
+
 func (a *DefaultAgent) FSM(b Agent, debug func(string)) {
 	a.StateMachine.Update(b, debug)
 }
@@ -0,0 +1,189 @@
+package goap
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// DomainFile is the format of a domain file. Action, goal and state names are the same as in code,
+// for example:
+//
+//	{
+//		"actions": [
+//			{"name": "getFood", "cost": 8, "effects": {"hasFood": true}, "requiresInRange": true, "perform": "pickUp"},
+//			{"name": "eat", "cost": 4, "preconditions": {"hasFood": true}, "effects": {"hungry": false}}
+//		],
+//		"goals": {"fed": {"hungry": false}},
+//		"state": {"hungry": true, "hasFood": false}
+//	}
+//
+// The fields have yaml tags as well, so that the same domain can be written in YAML.
+type DomainFile struct {
+	Actions []ActionSpec         `json:"actions" yaml:"actions"`
+	Goals   map[string]StateList `json:"goals" yaml:"goals"`
+	State   StateList            `json:"state" yaml:"state"`
+}
+
+// ActionSpec describes one action in a domain file.
+type ActionSpec struct {
+	Name            string    `json:"name" yaml:"name"`
+	Cost            float64   `json:"cost" yaml:"cost"`
+	Preconditions   StateList `json:"preconditions" yaml:"preconditions"`
+	Effects         StateList `json:"effects" yaml:"effects"`
+	RequiresInRange bool      `json:"requiresInRange" yaml:"requiresInRange"`
+	Range           float64   `json:"range" yaml:"range"`
+	Duration        float64   `json:"duration" yaml:"duration"`
+	Cooldown        int       `json:"cooldown" yaml:"cooldown"`
+
+	// The names of the registered behaviours used for Perform and CheckContextPrecondition. Without
+	// a perform behaviour the action is done as soon as it's performed, and without a check it can
+	// always be used.
+	Perform string `json:"perform" yaml:"perform"`
+	Check   string `json:"check" yaml:"check"`
+}
+
+// Domain is a loaded domain file.
+type Domain struct {
+	Actions []Action
+	Goals   map[string]StateList
+	State   StateList
+}
+
+// Behaviour is the Go code behind an action in a domain file.
+type Behaviour func(agent Agent, action *DomainAction) bool
+
+// Unmarshal decodes a domain file, json.Unmarshal and the Unmarshal of most YAML packages can be
+// used.
+type Unmarshal func(data []byte, v interface{}) error
+
+// NewRegistry creates an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		perform: make(map[string]Behaviour),
+		check:   make(map[string]Behaviour),
+	}
+}
+
+// Registry binds the behaviour names used in domain files to Go functions.
+type Registry struct {
+	perform map[string]Behaviour
+	check   map[string]Behaviour
+}
+
+// Perform registers a behaviour that actions can use to perform with "perform": name.
+func (r *Registry) Perform(name string, b Behaviour) {
+	r.perform[name] = b
+}
+
+// Check registers a behaviour that actions can use as their context precondition with
+// "check": name.
+func (r *Registry) Check(name string, b Behaviour) {
+	r.check[name] = b
+}
+
+// Load decodes a domain file with unmarshal, or as JSON if unmarshal is nil, and builds its
+// actions. It returns an error if the file can't be decoded, an action has no name or the same
+// name as another action, or it uses a behaviour that isn't registered. JSON files may only use the
+// keys of DomainFile and ActionSpec, so that a misspelled key is an error and not ignored.
+func (r *Registry) Load(data []byte, unmarshal Unmarshal) (*Domain, error) {
+	if unmarshal == nil {
+		unmarshal = strictJSON
+	}
+	var file DomainFile
+	if err := unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("goap: domain: %w", err)
+	}
+	return r.Build(&file)
+}
+
+// strictJSON decodes JSON like json.Unmarshal, but turns down unknown keys
+func strictJSON(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// Build creates the actions of a domain file, see Load.
+func (r *Registry) Build(file *DomainFile) (*Domain, error) {
+	d := &Domain{
+		Goals: file.Goals,
+		State: file.State,
+	}
+	if d.Goals == nil {
+		d.Goals = make(map[string]StateList)
+	}
+	if d.State == nil {
+		d.State = make(StateList)
+	}
+	names := make(map[string]bool)
+	for i := range file.Actions {
+		spec := file.Actions[i]
+		if spec.Name == "" {
+			return nil, fmt.Errorf("goap: domain: action %d has no name", i)
+		}
+		if names[spec.Name] {
+			return nil, fmt.Errorf("goap: domain: there is more than one action called %s", spec.Name)
+		}
+		names[spec.Name] = true
+		action, err := r.build(spec)
+		if err != nil {
+			return nil, err
+		}
+		d.Actions = append(d.Actions, action)
+	}
+	return d, nil
+}
+
+func (r *Registry) build(spec ActionSpec) (*DomainAction, error) {
+	a := &DomainAction{
+		DefaultAction: NewAction(spec.Name, spec.Cost),
+		Spec:          spec,
+	}
+	for name, value := range spec.Preconditions {
+		a.AddPrecondition(State{name, value})
+	}
+	for name, value := range spec.Effects {
+		a.AddEffect(State{name, value})
+	}
+	a.SetRequiresInRange(spec.RequiresInRange)
+	a.SetRange(spec.Range)
+	a.SetDuration(spec.Duration)
+	a.SetCooldown(spec.Cooldown)
+
+	if spec.Perform != "" {
+		if a.perform = r.perform[spec.Perform]; a.perform == nil {
+			return nil, fmt.Errorf("goap: domain: action %s: no perform behaviour called %s", spec.Name, spec.Perform)
+		}
+	}
+	if spec.Check != "" {
+		if a.check = r.check[spec.Check]; a.check == nil {
+			return nil, fmt.Errorf("goap: domain: action %s: no check behaviour called %s", spec.Name, spec.Check)
+		}
+	}
+	return a, nil
+}
+
+// DomainAction is an action loaded from a domain file.
+type DomainAction struct {
+	DefaultAction
+	Spec ActionSpec
+
+	perform Behaviour
+	check   Behaviour
+}
+
+func (a *DomainAction) CheckContextPrecondition(agent Agent) bool {
+	if a.check == nil {
+		return true
+	}
+	return a.check(agent, a)
+}
+
+func (a *DomainAction) Perform(agent Agent) bool {
+	if a.perform == nil {
+		a.Done = true
+		return true
+	}
+	return a.perform(agent, a)
+}
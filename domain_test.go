@@ -0,0 +1,94 @@
+package goap
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const testDomain = `{
+	"actions": [
+		{"name": "getFood", "cost": 8, "preconditions": {"hasFood": false}, "effects": {"hasFood": true}, "check": "foodNearby"},
+		{"name": "prayForFood", "cost": 1, "preconditions": {"hasFood": false}, "effects": {"hasFood": true}, "check": "believer"},
+		{"name": "eat", "cost": 4, "preconditions": {"hasFood": true, "hungry": true}, "effects": {"hungry": false, "hasFood": false}, "perform": "eat", "duration": 2}
+	],
+	"goals": {"fed": {"hungry": false}},
+	"state": {"hungry": true, "hasFood": false}
+}`
+
+func newTestRegistry(eaten *int) *Registry {
+	r := NewRegistry()
+	r.Check("foodNearby", func(agent Agent, action *DomainAction) bool {
+		return true
+	})
+	r.Check("believer", func(agent Agent, action *DomainAction) bool {
+		return false
+	})
+	r.Perform("eat", func(agent Agent, action *DomainAction) bool {
+		*eaten++
+		action.Done = true
+		return true
+	})
+	return r
+}
+
+func TestRegistry_Load(t *testing.T) {
+	eaten := 0
+	domain, err := newTestRegistry(&eaten).Load([]byte(testDomain), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(domain.Actions) != 3 || domain.Actions[2].Duration() != 2 {
+		t.Fatalf("expected three actions, got %v", domain.Actions)
+	}
+
+	actionList := Plan(&DefaultAgent{}, domain.Actions, domain.State, domain.Goals["fed"])
+	if len(actionList) != 2 || actionList[0].String() != "getFood" || actionList[1].String() != "eat" {
+		t.Fatalf("expected the unbelieving agent to get food and eat, got %v", actionList)
+	}
+	for _, action := range actionList {
+		action.Perform(nil)
+	}
+	if eaten != 1 || !actionList[0].IsDone() {
+		t.Errorf("expected eat to be performed through the registry and getFood to be done, got %d", eaten)
+	}
+}
+
+func TestRegistry_Load_unmarshal(t *testing.T) {
+	// a stand in for a YAML package
+	unmarshal := func(data []byte, v interface{}) error {
+		if string(data) != "actions: [{name: sleep}]" {
+			return errors.New("unexpected input")
+		}
+		v.(*DomainFile).Actions = []ActionSpec{{Name: "sleep", Cost: 1, RequiresInRange: true}}
+		return nil
+	}
+	domain, err := NewRegistry().Load([]byte("actions: [{name: sleep}]"), unmarshal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(domain.Actions) != 1 || !domain.Actions[0].RequiresInRange() {
+		t.Errorf("expected the sleep action to require range, got %v", domain.Actions)
+	}
+	if domain.Goals == nil || domain.State == nil {
+		t.Error("expected empty goals and state rather than nil")
+	}
+}
+
+func TestRegistry_Load_errors(t *testing.T) {
+	tests := map[string]string{
+		`{"actions": [{"cost": 1}]}`:                       "has no name",
+		`{"actions": [{"name": "a"}, {"name": "a"}]}`:      "more than one action called a",
+		`{"actions": [{"name": "a", "perform": "fly"}]}`:   "no perform behaviour called fly",
+		`{"actions": [{"name": "a", "check": "psychic"}]}`: "no check behaviour called psychic",
+		`{"actions": 1}`: "domain",
+		`{"actions": [{"name": "eat", "precondition": {"hasFood": true}}]}`: "unknown field \"precondition\"",
+		`{"actions": [{"name": "eat", "effect": {"hungry": false}}]}`:       "unknown field \"effect\"",
+	}
+	for data, expected := range tests {
+		_, err := NewRegistry().Load([]byte(data), nil)
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("expected an error containing %q for %s, got %v", expected, data, err)
+		}
+	}
+}
@@ -95,7 +95,7 @@ func ExamplePlan() {
 	agent.Update()
 
 	// 3. Move to food, it instantly succeeds
-	getFood.inRange = true
+	agent.CurrentActions()[0].(*getFoodAction).inRange = true
 	agent.Update()
 
 	// 4. We have moved and food is in range, so run getFood action
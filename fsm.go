@@ -2,21 +2,67 @@ package goap
 
 import (
 	"fmt"
+	"math"
 )
 
 type FSMState func(fsm *FSM, obj Agent, debug func(string))
 
+// NewFSM creates a state machine in the start state. The start state is also the state it goes back
+// to when the plan is finished or aborted, it's normally Idle.
 func NewFSM(startState FSMState) *FSM {
-	fsm := &FSM{}
+	fsm := &FSM{idle: startState}
 	fsm.Reset(startState)
 	return fsm
 }
 
 type FSM struct {
 	stateStack []FSMState
+	// the planning state to go back to, Idle if nil
+	idle FSMState
+
+	// Planner used by the Idle state, Plan is used if this is nil. If the planner has Cooldowns
+	// they record the actions that failed or finished. They are ticked on every update if the FSM
+	// has no Scheduler, otherwise the scheduler ticks them, see Cooldowns.
+	Planner *Planner
+
+	// Pool, if set, is used by Idle to plan in the background.
+	Pool *PlanPool
+	// the plan request Idle is waiting for
+	pending *PlanRequest
+
+	// Scheduler, if set, is asked by the planning states before they plan, so that planning can be
+	// spread out over several updates.
+	Scheduler PlanScheduler
+
+	// Navigator used by the MoveTo state, agent.MoveAgent is used if this is nil.
+	Navigator Navigator
+	// If larger than zero, MoveTo aborts the plan when the Navigator hasn't got the agent any
+	// closer to its target for this many updates.
+	StuckLimit int
+
+	// Why the last plan was aborted, it wraps ErrActionFailed, ErrUnreachable or ErrStuck.
+	AbortReason error
+
+	// consecutive failures of the current action and updates left to wait before retrying it
+	failures int
+	wait     int
+
+	// the partial plan run by ParallelDo, which of its actions are finished and how they are
+	// being retried
+	partial  *PartialPlan
+	finished []bool
+	retries  []retryState
+
+	// the navigator has a path for the current action, and how it's been progressing
+	pathRequested bool
+	closest       float64
+	stuck         int
 }
 
 func (fsm *FSM) Update(agent Agent, debug func(string)) {
+	if c := fsm.cooldowns(); c != nil && fsm.Scheduler == nil {
+		c.Tick()
+	}
 	if len(fsm.stateStack) > 0 {
 		fsm.stateStack[len(fsm.stateStack)-1](fsm, agent, debug)
 	}
@@ -31,6 +77,10 @@ func (fsm *FSM) Reset(state FSMState) {
 	for i := 0; i < states; i++ {
 		fsm.Pop()
 	}
+	fsm.failures = 0
+	fsm.wait = 0
+	fsm.pathRequested = false
+	fsm.pending = nil
 	fsm.Push(state)
 }
 
@@ -40,24 +90,103 @@ func (fsm *FSM) Pop() {
 	}
 }
 
+// replan goes back to the planning state
+func (fsm *FSM) replan() {
+	if fsm.idle == nil {
+		fsm.Reset(Idle)
+		return
+	}
+	fsm.Reset(fsm.idle)
+}
+
+func (fsm *FSM) planner() *Planner {
+	if fsm.Planner == nil {
+		return &Planner{}
+	}
+	return fsm.Planner
+}
+
+func (fsm *FSM) cooldowns() *Cooldowns {
+	if fsm.Planner == nil {
+		return nil
+	}
+	return fsm.Planner.Cooldowns
+}
+
+// allowPlan asks the scheduler if the agent may plan now
+func (fsm *FSM) allowPlan(agent Agent) bool {
+	return fsm.Scheduler == nil || fsm.Scheduler.AllowPlan(agent)
+}
+
+// reserve the targets of the plan for the agent. If another agent has reserved one of them the
+// agent's reservations are released and false is returned.
+func (fsm *FSM) reserve(agent Agent, plan []Action) bool {
+	if fsm.Planner == nil || fsm.Planner.Reservations == nil {
+		return true
+	}
+	for _, action := range plan {
+		if t := action.Target(); t != nil && !fsm.Planner.Reservations.Reserve(t, agent) {
+			fsm.Planner.Reservations.ReleaseAll(agent)
+			return false
+		}
+	}
+	return true
+}
+
+// release all targets reserved by the agent
+func (fsm *FSM) release(agent Agent) {
+	if fsm.Planner != nil && fsm.Planner.Reservations != nil {
+		fsm.Planner.Reservations.ReleaseAll(agent)
+	}
+}
+
+// finish the plan and go back to planning
+func (fsm *FSM) finish(agent Agent) {
+	fsm.release(agent)
+	fsm.replan()
+	agent.ActionsFinished()
+}
+
 func Idle(fsm *FSM, agent Agent, debug func(string)) {
-	debug("Idle - is planning")
 	goal := agent.GoalState()
-	plan := Plan(agent, agent.AvailableActions(), agent.State(), goal)
+	var plan []Action
+	if fsm.Pool != nil {
+		var ready bool
+		if plan, ready = fsm.planAsync(agent, goal, debug); !ready {
+			return
+		}
+	} else {
+		if !fsm.allowPlan(agent) {
+			debug("Idle - waiting to plan")
+			return
+		}
+		debug("Idle - is planning")
+		plan = fsm.planner().Plan(agent, agent.AvailableActions(), agent.State(), goal)
+	}
 	if plan == nil {
 		agent.PlanFailed(goal)
 		return
 	}
+	if !fsm.reserve(agent, plan) {
+		debug("Idle - a target in the plan is reserved")
+		agent.PlanFailed(goal)
+		return
+	}
 	agent.SetCurrentActions(plan)
 	agent.PlanFound(goal, plan)
 	fsm.Reset(Do)
 }
 
 func Do(fsm *FSM, agent Agent, debug func(string)) {
+	// backing off before retrying a failed action
+	if fsm.wait > 0 {
+		fsm.wait--
+		return
+	}
+
 	// no actions to perform
 	if len(agent.CurrentActions()) == 0 {
-		fsm.Reset(Idle)
-		agent.ActionsFinished()
+		fsm.finish(agent)
 		return
 	}
 
@@ -66,17 +195,35 @@ func Do(fsm *FSM, agent Agent, debug func(string)) {
 		debug(fmt.Sprintf("Do - action %s is done", action))
 		// the action is done. Remove it so we can perform the next one
 		agent.PopCurrentAction()
+		fsm.failures = 0
+		if c := fsm.cooldowns(); c != nil {
+			c.Used(action)
+		}
 	}
 
 	if len(agent.CurrentActions()) == 0 {
-		fsm.Reset(Idle)
-		agent.ActionsFinished()
+		fsm.finish(agent)
 		return
 	}
 
 	action = agent.CurrentActions()[0]
+	// macro actions are replaced with the actions they are made of
+	for {
+		macro, ok := action.(*MacroAction)
+		if !ok {
+			break
+		}
+		debug(fmt.Sprintf("Do - expanding %s", macro))
+		actions := append([]Action{}, macro.Actions()...)
+		agent.SetCurrentActions(append(actions, agent.CurrentActions()[1:]...))
+		if len(agent.CurrentActions()) == 0 {
+			return
+		}
+		action = agent.CurrentActions()[0]
+	}
+
 	// we need to move there first
-	if !action.InRange(agent) {
+	if action.RequiresInRange() && !action.InRange(agent) {
 		debug(fmt.Sprintf("Do - scheduling moveTo %s", action))
 		fsm.Push(MoveTo)
 		return
@@ -84,12 +231,57 @@ func Do(fsm *FSM, agent Agent, debug func(string)) {
 
 	// we are in range, so perform the action
 	debug(fmt.Sprintf("Do - %s.Perform()", action))
-	success := action.Perform(agent)
-	// action failed, we need to plan again
-	if !success {
-		fsm.Reset(Idle)
-		agent.PlanAborted(action)
+	if action.Perform(agent) {
+		fsm.failures = 0
+		return
+	}
+	fsm.failed(agent, action, debug)
+}
+
+// retryState is the consecutive failures of an action and the updates left to wait before retrying
+type retryState struct {
+	failures int
+	wait     int
+}
+
+// failed is called when action.Perform returned false. Depending on the action's RetryPolicy the
+// action is retried, skipped, or the plan is aborted so that we can plan again.
+func (fsm *FSM) failed(agent Agent, action Action, debug func(string)) {
+	policy := action.RetryPolicy()
+	if fsm.failures < policy.Retries {
+		fsm.failures++
+		fsm.wait = policy.Backoff * fsm.failures
+		debug(fmt.Sprintf("Do - %s failed, retry %d of %d", action, fsm.failures, policy.Retries))
+		return
+	}
+
+	fsm.failures = 0
+	if policy.OnFailure == SkipAction {
+		debug(fmt.Sprintf("Do - %s failed, skipping it", action))
+		if c := fsm.cooldowns(); c != nil {
+			c.Failed(action)
+		}
+		agent.PopCurrentAction()
+		return
 	}
+
+	fsm.abort(agent, action, ErrActionFailed)
+}
+
+// abort the plan because of the action, and go back to planning
+func (fsm *FSM) abort(agent Agent, action Action, reason error) {
+	if c := fsm.cooldowns(); c != nil {
+		c.Failed(action)
+	}
+	fsm.release(agent)
+	if f, ok := fsm.Navigator.(interface{ Forget(Agent) }); ok {
+		f.Forget(agent)
+	}
+	fsm.replan()
+	fsm.AbortReason = fmt.Errorf("%s: %w", action, reason)
+	abandonAssignments(agent.CurrentActions())
+	agent.SetCurrentActions(nil)
+	agent.PlanAborted(action)
 }
 
 func MoveTo(fsm *FSM, agent Agent, debug func(string)) {
@@ -97,14 +289,80 @@ func MoveTo(fsm *FSM, agent Agent, debug func(string)) {
 
 	if action.Target() == nil {
 		debug("Error: MoveTo requires a target but has none. Planning failed. You did not assign the target in your Action.CheckContextPrecondition()")
-		fsm.Reset(Idle)
+		fsm.abort(agent, action, ErrNoTarget)
 		return
 	}
 
-	// get the agent to move itself
-	debug(fmt.Sprintf("MoveTo - MoveAgent(%s)", action))
-	if agent.MoveAgent(action) {
+	if fsm.Navigator == nil {
+		// get the agent to move itself
+		debug(fmt.Sprintf("MoveTo - MoveAgent(%s)", action))
+		if agent.MoveAgent(action) {
+			debug("MoveTo - done")
+			fsm.Pop()
+		}
+		return
+	}
+
+	if !fsm.pathRequested {
+		debug(fmt.Sprintf("MoveTo - RequestPath(%s)", action))
+		if !fsm.requestPath(agent, action) {
+			debug("MoveTo - target is unreachable")
+			fsm.abort(agent, action, ErrUnreachable)
+			return
+		}
+		fsm.pathRequested = true
+		fsm.closest = math.Inf(1)
+		fsm.stuck = 0
+	}
+
+	progress := fsm.Navigator.Move(agent)
+	switch progress.Status {
+	case NavArrived:
+		fsm.pathRequested = false
+		if action.RequiresInRange() && !action.InRange(agent) {
+			// moving again won't help, and would never end
+			debug("MoveTo - arrived but not in range")
+			fsm.abort(agent, action, ErrUnreachable)
+			return
+		}
 		debug("MoveTo - done")
 		fsm.Pop()
+	case NavUnreachable:
+		debug("MoveTo - target is unreachable")
+		fsm.abort(agent, action, ErrUnreachable)
+	default:
+		if action.RequiresInRange() && action.InRange(agent) {
+			// no need to walk the rest of the way
+			debug("MoveTo - in range")
+			fsm.pathRequested = false
+			if f, ok := fsm.Navigator.(interface{ Forget(Agent) }); ok {
+				f.Forget(agent)
+			}
+			fsm.Pop()
+			return
+		}
+		if progress.Remaining < fsm.closest {
+			fsm.closest = progress.Remaining
+			fsm.stuck = 0
+			return
+		}
+		fsm.stuck++
+		if fsm.StuckLimit > 0 && fsm.stuck >= fsm.StuckLimit {
+			debug("MoveTo - agent is stuck")
+			fsm.abort(agent, action, ErrStuck)
+		}
+	}
+}
+
+// requestPath asks the navigator for a path to the target of the action. Navigators that implement
+// RequestPathInRange are given the range of actions that require it.
+func (fsm *FSM) requestPath(agent Agent, action Action) bool {
+	n, ok := fsm.Navigator.(interface {
+		RequestPathInRange(Agent, interface{}, float64) bool
+	})
+	a, hasRange := action.(interface{ Range() float64 })
+	if ok && hasRange && action.RequiresInRange() {
+		return n.RequestPathInRange(agent, action.Target(), a.Range())
 	}
+	return fsm.Navigator.RequestPath(agent, action.Target())
 }
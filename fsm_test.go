@@ -0,0 +1,125 @@
+package goap
+
+import (
+	"testing"
+)
+
+func newFlakyAction(name string, cost float64, failures int) *flakyAction {
+	return &flakyAction{
+		DefaultAction: NewAction(name, cost),
+		failures:      failures,
+		performs:      new(int),
+	}
+}
+
+// flakyAction fails the first n times it is performed
+type flakyAction struct {
+	DefaultAction
+	failures int
+	// shared by all instances of the action
+	performs *int
+}
+
+func (a *flakyAction) Perform(agent Agent) bool {
+	*a.performs++
+	if *a.performs <= a.failures {
+		return false
+	}
+	a.Done = true
+	return true
+}
+
+func (a *flakyAction) InRange(agent Agent) bool {
+	return true
+}
+
+type recordingAgent struct {
+	DefaultAgent
+	aborted  []Action
+	finished int
+}
+
+func (a *recordingAgent) PlanAborted(aborter Action) {
+	a.aborted = append(a.aborted, aborter)
+}
+
+func (a *recordingAgent) ActionsFinished() {
+	a.finished++
+}
+
+func (a *recordingAgent) Update() {
+	a.FSM(a, func(string) {})
+}
+
+func newRecordingAgent(actions ...Action) *recordingAgent {
+	agent := &recordingAgent{DefaultAgent: NewDefaultAgent(actions)}
+	agent.SetState(make(StateList))
+	return agent
+}
+
+func TestDo_retry(t *testing.T) {
+	flaky := newFlakyAction("flaky", 1, 2)
+	flaky.AddEffect(HaveFood)
+	flaky.SetRetryPolicy(RetryPolicy{Retries: 2, Backoff: 1})
+
+	agent := newRecordingAgent(flaky)
+	agent.SetGoalState(StateList{HaveFood.Name: true})
+
+	// plan, fail, wait, fail, wait, wait, succeed, finish
+	for i := 0; i < 8; i++ {
+		agent.Update()
+	}
+
+	if *flaky.performs != 3 {
+		t.Errorf("expected the action to be performed 3 times, got %d", *flaky.performs)
+	}
+	if len(agent.aborted) != 0 {
+		t.Errorf("expected the plan not to be aborted, got %v", agent.aborted)
+	}
+	if agent.finished != 1 {
+		t.Errorf("expected the actions to finish once, got %d", agent.finished)
+	}
+}
+
+func TestDo_retry_exhausted(t *testing.T) {
+	flaky := newFlakyAction("flaky", 1, 5)
+	flaky.AddEffect(HaveFood)
+	flaky.SetRetryPolicy(RetryPolicy{Retries: 1})
+
+	agent := newRecordingAgent(flaky)
+	agent.SetGoalState(StateList{HaveFood.Name: true})
+
+	for i := 0; i < 3; i++ {
+		agent.Update()
+	}
+
+	if len(agent.aborted) != 1 || Definition(agent.aborted[0]) != flaky {
+		t.Errorf("expected the plan to be aborted by flaky, got %v", agent.aborted)
+	}
+}
+
+func TestDo_skip(t *testing.T) {
+	flaky := newFlakyAction("flaky", 1, 5)
+	flaky.AddEffect(HaveFood)
+	flaky.SetRetryPolicy(RetryPolicy{OnFailure: SkipAction})
+
+	eat := newFlakyAction("eat", 1, 0)
+	eat.AddPrecondition(HaveFood)
+	eat.AddEffect(Isnt(Hungry))
+
+	agent := newRecordingAgent(flaky, eat)
+	goal := make(StateList)
+	goal.Isnt(Hungry)
+	agent.SetGoalState(goal)
+
+	for i := 0; i < 4; i++ {
+		agent.Update()
+	}
+
+	if len(agent.aborted) != 0 {
+		t.Errorf("expected the plan not to be aborted, got %v", agent.aborted)
+	}
+	if *eat.performs != 1 {
+		t.Errorf("expected eat to be performed after flaky was skipped, got %d performs", *eat.performs)
+	}
+}
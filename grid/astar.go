@@ -0,0 +1,177 @@
+package grid
+
+import (
+	"container/heap"
+	"math"
+)
+
+// Diagonal decides if paths may move diagonally between tiles.
+type Diagonal int
+
+const (
+	// NoDiagonals only moves up, down, left and right.
+	NoDiagonals Diagonal = iota
+	// DiagonalsNoCorners moves diagonally unless one of the tiles next to the move is blocked, so
+	// that paths don't cut corners.
+	DiagonalsNoCorners
+	// Diagonals always allows moving diagonally between walkable tiles.
+	Diagonals
+)
+
+var (
+	straight = []Point{{0, -1}, {1, 0}, {0, 1}, {-1, 0}}
+	diagonal = []Point{{1, -1}, {1, 1}, {-1, 1}, {-1, -1}}
+)
+
+// Path finds the shortest path between two walkable tiles with A*. The path doesn't include from,
+// but ends with to. It returns nil if there is no path, and an empty path if from and to are the
+// same tile.
+func (g *Grid) Path(from, to Point, diagonals Diagonal) []Point {
+	if !g.Walkable(to) {
+		return nil
+	}
+	return g.search(from, func(p Point) bool { return p == to }, func(p Point) float64 {
+		return heuristic(p, to, diagonals)
+	}, diagonals)
+}
+
+// PathInRange finds the shortest path from a walkable tile to the nearest walkable tile within
+// radius tiles of to, which itself doesn't have to be walkable. It returns nil if there is no
+// path, and an empty path if from is already in range.
+func (g *Grid) PathInRange(from, to Point, radius float64, diagonals Diagonal) []Point {
+	return g.search(from, func(p Point) bool { return distance(p, to) <= radius }, func(p Point) float64 {
+		// the heuristic is at most √2 times the straight distance, so this never overestimates
+		return math.Max(0, heuristic(p, to, diagonals)-radius*math.Sqrt2)
+	}, diagonals)
+}
+
+// search is A* from a walkable tile to the first tile that is a goal
+func (g *Grid) search(from Point, goal func(Point) bool, estimate func(Point) float64, diagonals Diagonal) []Point {
+	if !g.Walkable(from) {
+		return nil
+	}
+	if goal(from) {
+		return []Point{}
+	}
+
+	start := &pathNode{point: from, estimate: estimate(from)}
+	open := &openList{start}
+	nodes := map[Point]*pathNode{from: start}
+
+	for open.Len() > 0 {
+		current := heap.Pop(open).(*pathNode)
+		if goal(current.point) {
+			return current.path()
+		}
+		current.closed = true
+
+		for _, n := range g.neighbours(current.point, diagonals) {
+			cost := current.cost + 1
+			if n.X != current.point.X && n.Y != current.point.Y {
+				cost = current.cost + math.Sqrt2
+			}
+			next, seen := nodes[n]
+			if seen && (next.closed || next.cost <= cost) {
+				continue
+			}
+			if !seen {
+				next = &pathNode{point: n}
+				nodes[n] = next
+			}
+			next.parent = current
+			next.cost = cost
+			next.estimate = cost + estimate(n)
+			if seen {
+				heap.Fix(open, next.index)
+			} else {
+				heap.Push(open, next)
+			}
+		}
+	}
+	return nil
+}
+
+func (g *Grid) neighbours(p Point, diagonals Diagonal) []Point {
+	var result []Point
+	for _, d := range straight {
+		n := Point{p.X + d.X, p.Y + d.Y}
+		if g.Walkable(n) {
+			result = append(result, n)
+		}
+	}
+	if diagonals == NoDiagonals {
+		return result
+	}
+	for _, d := range diagonal {
+		n := Point{p.X + d.X, p.Y + d.Y}
+		if !g.Walkable(n) {
+			continue
+		}
+		if diagonals == DiagonalsNoCorners && (!g.Walkable(Point{p.X + d.X, p.Y}) || !g.Walkable(Point{p.X, p.Y + d.Y})) {
+			continue
+		}
+		result = append(result, n)
+	}
+	return result
+}
+
+// heuristic is the manhattan distance, or the octile distance when moving diagonally
+func heuristic(a, b Point, diagonals Diagonal) float64 {
+	dx := math.Abs(float64(a.X - b.X))
+	dy := math.Abs(float64(a.Y - b.Y))
+	if diagonals == NoDiagonals {
+		return dx + dy
+	}
+	return dx + dy + (math.Sqrt2-2)*math.Min(dx, dy)
+}
+
+// distance is the straight distance between the centres of two tiles
+func distance(a, b Point) float64 {
+	dx, dy := float64(a.X-b.X), float64(a.Y-b.Y)
+	return math.Sqrt(dx*dx + dy*dy)
+}
+
+type pathNode struct {
+	point    Point
+	parent   *pathNode
+	cost     float64
+	estimate float64
+	closed   bool
+	index    int
+}
+
+func (n *pathNode) path() []Point {
+	var result []Point
+	for ; n.parent != nil; n = n.parent {
+		result = append(result, n.point)
+	}
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	return result
+}
+
+// openList is a priority queue of nodes ordered by their estimated total cost
+type openList []*pathNode
+
+func (l openList) Len() int           { return len(l) }
+func (l openList) Less(i, j int) bool { return l[i].estimate < l[j].estimate }
+
+func (l openList) Swap(i, j int) {
+	l[i], l[j] = l[j], l[i]
+	l[i].index = i
+	l[j].index = j
+}
+
+func (l *openList) Push(x interface{}) {
+	n := x.(*pathNode)
+	n.index = len(*l)
+	*l = append(*l, n)
+}
+
+func (l *openList) Pop() interface{} {
+	old := *l
+	n := old[len(old)-1]
+	*l = old[:len(old)-1]
+	return n
+}
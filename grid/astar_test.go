@@ -0,0 +1,79 @@
+package grid
+
+import (
+	"testing"
+)
+
+func TestGrid_Path(t *testing.T) {
+	g := New(5, 5)
+	// a wall with a gap at the bottom
+	for y := 0; y < 4; y++ {
+		g.SetWalkable(Point{2, y}, false)
+	}
+
+	path := g.Path(Point{0, 0}, Point{4, 0}, NoDiagonals)
+	if len(path) != 12 {
+		t.Fatalf("expected a path of 12 steps around the wall, got %d: %v", len(path), path)
+	}
+	if path[len(path)-1] != (Point{4, 0}) {
+		t.Errorf("expected the path to end at the goal, got %v", path[len(path)-1])
+	}
+	for _, p := range path {
+		if !g.Walkable(p) {
+			t.Errorf("path goes through blocked tile %v", p)
+		}
+	}
+}
+
+func TestGrid_Path_diagonals(t *testing.T) {
+	g := New(5, 5)
+
+	if path := g.Path(Point{0, 0}, Point{4, 4}, Diagonals); len(path) != 4 {
+		t.Errorf("expected a diagonal path of 4 steps, got %v", path)
+	}
+	if path := g.Path(Point{0, 0}, Point{4, 4}, NoDiagonals); len(path) != 8 {
+		t.Errorf("expected a path of 8 steps, got %v", path)
+	}
+}
+
+func TestGrid_Path_corners(t *testing.T) {
+	g := New(2, 2)
+	g.SetWalkable(Point{1, 0}, false)
+
+	if path := g.Path(Point{0, 0}, Point{1, 1}, Diagonals); len(path) != 1 {
+		t.Errorf("expected to cut the corner, got %v", path)
+	}
+	if path := g.Path(Point{0, 0}, Point{1, 1}, DiagonalsNoCorners); len(path) != 2 {
+		t.Errorf("expected to walk around the corner, got %v", path)
+	}
+}
+
+func TestGrid_Path_none(t *testing.T) {
+	g := New(3, 3)
+	for y := 0; y < 3; y++ {
+		g.SetWalkable(Point{1, y}, false)
+	}
+
+	if path := g.Path(Point{0, 0}, Point{2, 0}, Diagonals); path != nil {
+		t.Errorf("expected no path, got %v", path)
+	}
+	if path := g.Path(Point{0, 0}, Point{0, 0}, Diagonals); path == nil || len(path) != 0 {
+		t.Errorf("expected an empty path to the same tile, got %v", path)
+	}
+}
+
+func TestGrid_PathInRange(t *testing.T) {
+	g := New(5, 5)
+	g.SetWalkable(Point{4, 0}, false)
+
+	path := g.PathInRange(Point{0, 0}, Point{4, 0}, 1, NoDiagonals)
+	if len(path) != 3 || path[len(path)-1] != (Point{3, 0}) {
+		t.Errorf("expected to stop next to the blocked tile, got %v", path)
+	}
+	if path := g.PathInRange(Point{3, 0}, Point{4, 0}, 1, NoDiagonals); path == nil || len(path) != 0 {
+		t.Errorf("expected an empty path when already in range, got %v", path)
+	}
+	if path := g.Path(Point{0, 0}, Point{4, 0}, NoDiagonals); path != nil {
+		t.Errorf("expected no path onto the blocked tile, got %v", path)
+	}
+}
@@ -0,0 +1,42 @@
+// Package grid is a 2D tile map with A* pathfinding, and a goap.Navigator that moves agents across
+// it one tile per update.
+package grid
+
+// Point is the position of a tile.
+type Point struct {
+	X, Y int
+}
+
+// New creates a grid where all tiles are walkable.
+func New(width, height int) *Grid {
+	return &Grid{
+		Width:   width,
+		Height:  height,
+		blocked: make([]bool, width*height),
+	}
+}
+
+// Grid is a map of walkable and blocked tiles.
+type Grid struct {
+	Width  int
+	Height int
+
+	blocked []bool
+}
+
+// InBounds returns true if the point is on the grid.
+func (g *Grid) InBounds(p Point) bool {
+	return p.X >= 0 && p.Y >= 0 && p.X < g.Width && p.Y < g.Height
+}
+
+// Walkable returns true if the point is on the grid and not blocked.
+func (g *Grid) Walkable(p Point) bool {
+	return g.InBounds(p) && !g.blocked[p.Y*g.Width+p.X]
+}
+
+// SetWalkable blocks or unblocks a tile. Points outside the grid are ignored.
+func (g *Grid) SetWalkable(p Point, walkable bool) {
+	if g.InBounds(p) {
+		g.blocked[p.Y*g.Width+p.X] = !walkable
+	}
+}
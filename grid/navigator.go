@@ -0,0 +1,195 @@
+package grid
+
+import (
+	"math"
+
+	"github.com/stojg/goap"
+)
+
+// Mover is implemented by agents that walk on a grid.
+type Mover interface {
+	GridPosition() Point
+	SetGridPosition(Point)
+}
+
+// Located is implemented by targets that are on a grid.
+type Located interface {
+	GridPosition() Point
+}
+
+// NewNavigator creates a navigator that moves agents across the grid.
+func NewNavigator(g *Grid, diagonals Diagonal) *Navigator {
+	return &Navigator{
+		Grid:      g,
+		Diagonals: diagonals,
+		routes:    make(map[goap.Agent]*route),
+	}
+}
+
+// Navigator is a goap.Navigator that moves agents one tile per update. Agents must be a Mover, and
+// targets must be a Point, a Located or a goap.Vector, whose X and Y are rounded to the nearest
+// tile.
+//
+// If a tile on the path gets blocked, a new path is found. If there is none the target is
+// unreachable. Actions that require range should use RangeChecker, the FSM then asks for a path
+// with RequestPathInRange, which stops at the nearest walkable tile in range, so that targets on a
+// blocked tile, like a tree or a wall, can be reached.
+type Navigator struct {
+	Grid      *Grid
+	Diagonals Diagonal
+
+	routes map[goap.Agent]*route
+}
+
+type route struct {
+	target Point
+	radius float64
+	path   []Point
+}
+
+// RequestPath implements goap.Navigator.
+func (n *Navigator) RequestPath(agent goap.Agent, target interface{}) bool {
+	return n.RequestPathInRange(agent, target, 0)
+}
+
+// RequestPathInRange finds a path for the agent to the nearest walkable tile within radius tiles of
+// the target, with a radius of zero it's the target's own tile. It returns false if there is none.
+func (n *Navigator) RequestPathInRange(agent goap.Agent, target interface{}, radius float64) bool {
+	mover, ok := agent.(Mover)
+	if !ok {
+		return false
+	}
+	to, ok := pointOf(target)
+	if !ok {
+		return false
+	}
+	r := &route{target: to, radius: radius}
+	r.path = n.path(mover.GridPosition(), r)
+	if r.path == nil {
+		delete(n.routes, agent)
+		return false
+	}
+	n.routes[agent] = r
+	return true
+}
+
+func (n *Navigator) path(from Point, r *route) []Point {
+	if r.radius > 0 {
+		return n.Grid.PathInRange(from, r.target, r.radius, n.Diagonals)
+	}
+	return n.Grid.Path(from, r.target, n.Diagonals)
+}
+
+// Move implements goap.Navigator.
+func (n *Navigator) Move(agent goap.Agent) goap.NavProgress {
+	mover, ok := agent.(Mover)
+	r, found := n.routes[agent]
+	if !ok || !found {
+		return goap.NavProgress{Status: goap.NavUnreachable}
+	}
+
+	if len(r.path) > 0 && !n.Grid.Walkable(r.path[0]) {
+		// the way is blocked, try to find another one
+		r.path = n.path(mover.GridPosition(), r)
+		if r.path == nil {
+			delete(n.routes, agent)
+			return goap.NavProgress{Status: goap.NavUnreachable}
+		}
+	}
+
+	if len(r.path) > 0 {
+		mover.SetGridPosition(r.path[0])
+		r.path = r.path[1:]
+	}
+
+	if len(r.path) == 0 {
+		delete(n.routes, agent)
+		return goap.NavProgress{Status: goap.NavArrived}
+	}
+	return goap.NavProgress{Status: goap.NavMoving, Remaining: float64(len(r.path))}
+}
+
+// Forget drops the path of the agent. The FSM calls it when a plan is aborted, and it should be
+// called for agents that leave the game while moving.
+func (n *Navigator) Forget(agent goap.Agent) {
+	delete(n.routes, agent)
+}
+
+// MoveAgent moves the agent one tile towards the target of the action, it can be used to implement
+// goap.Agent.MoveAgent without setting the navigator on the FSM. It returns true when the agent has
+// arrived, or is within the range of an action that requires range. An unreachable target is never
+// arrived at.
+func (n *Navigator) MoveAgent(agent goap.Agent, action goap.Action) bool {
+	to, ok := pointOf(action.Target())
+	if !ok {
+		return false
+	}
+	radius := 0.0
+	if a, ok := action.(interface{ Range() float64 }); ok && action.RequiresInRange() {
+		radius = a.Range()
+	}
+	if r, found := n.routes[agent]; !found || r.target != to || r.radius != radius {
+		if !n.RequestPathInRange(agent, to, radius) {
+			return false
+		}
+	}
+	return n.Move(agent).Status == goap.NavArrived
+}
+
+// TravelCost is a goap.TravelCost of the length of the path between two places. Places that can't
+// be reached cost math.Inf(1).
+func (n *Navigator) TravelCost(agent goap.Agent, from, to interface{}) float64 {
+	a, ok := pointOf(from)
+	if !ok {
+		return math.Inf(1)
+	}
+	b, ok := pointOf(to)
+	if !ok {
+		return math.Inf(1)
+	}
+	path := n.Grid.Path(a, b, n.Diagonals)
+	if path == nil {
+		return math.Inf(1)
+	}
+	cost := 0.0
+	for _, p := range path {
+		if p.X != a.X && p.Y != a.Y {
+			cost += math.Sqrt2
+		} else {
+			cost++
+		}
+		a = p
+	}
+	return cost
+}
+
+// RangeChecker is a goap.RangeChecker for agents that are a Mover and targets that the Navigator
+// can walk to. The agent is in range when it's within radius tiles of the target, with a radius of
+// zero it has to be on the target's tile.
+func RangeChecker(agent goap.Agent, target interface{}, radius float64) bool {
+	mover, ok := agent.(Mover)
+	if !ok {
+		return false
+	}
+	to, ok := pointOf(target)
+	if !ok {
+		return false
+	}
+	return distance(mover.GridPosition(), to) <= radius
+}
+
+func pointOf(target interface{}) (Point, bool) {
+	switch t := target.(type) {
+	case Point:
+		return t, true
+	case *Point:
+		if t != nil {
+			return *t, true
+		}
+	case Located:
+		return t.GridPosition(), true
+	case goap.Vector:
+		return Point{int(math.Round(t.X)), int(math.Round(t.Y))}, true
+	}
+	return Point{}, false
+}
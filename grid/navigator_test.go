@@ -0,0 +1,241 @@
+package grid
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stojg/goap"
+)
+
+type gridAgent struct {
+	goap.DefaultAgent
+	position Point
+}
+
+func (a *gridAgent) GridPosition() Point {
+	return a.position
+}
+
+func (a *gridAgent) SetGridPosition(p Point) {
+	a.position = p
+}
+
+func (a *gridAgent) Update() {
+	a.FSM(a, func(string) {})
+}
+
+type chopAction struct {
+	goap.DefaultAction
+	tree Point
+}
+
+func (a *chopAction) CheckContextPrecondition(agent goap.Agent) bool {
+	a.SetTarget(a.tree)
+	return true
+}
+
+func (a *chopAction) Perform(agent goap.Agent) bool {
+	a.Done = true
+	return true
+}
+
+func newGridAgent(g *Grid, tree Point) *gridAgent {
+	chop := &chopAction{DefaultAction: goap.NewAction("chop", 1), tree: tree}
+	chop.SetRequiresInRange(true)
+	chop.SetRangeChecker(RangeChecker)
+	chop.AddEffect(goap.State{Name: "hasWood", Value: true})
+
+	agent := &gridAgent{DefaultAgent: goap.NewDefaultAgent([]goap.Action{chop})}
+	agent.SetState(make(goap.StateList))
+	agent.SetGoalState(goap.StateList{"hasWood": true})
+	agent.StateMachine.Navigator = NewNavigator(g, DiagonalsNoCorners)
+	return agent
+}
+
+func TestNavigator(t *testing.T) {
+	agent := newGridAgent(New(10, 10), Point{3, 0})
+
+	// plan, schedule move, three moves, chop
+	for i := 0; i < 6; i++ {
+		agent.Update()
+	}
+
+	if agent.position != (Point{3, 0}) {
+		t.Errorf("expected the agent to walk to the tree, it's at %v", agent.position)
+	}
+	if !agent.CurrentActions()[0].IsDone() {
+		t.Error("expected the agent to have chopped the tree")
+	}
+}
+
+func TestNavigator_blocked_target(t *testing.T) {
+	g := New(10, 10)
+	g.SetWalkable(Point{3, 0}, false)
+	agent := newGridAgent(g, Point{3, 0})
+	agent.AvailableActions()[0].(*chopAction).SetRange(1.5)
+
+	// plan, schedule move, two moves, chop
+	for i := 0; i < 5; i++ {
+		agent.Update()
+	}
+
+	if !RangeChecker(agent, Point{3, 0}, 1.5) {
+		t.Errorf("expected the agent to walk next to the tree, it's at %v", agent.position)
+	}
+	if agent.StateMachine.AbortReason != nil {
+		t.Errorf("expected the tree to be reachable, got %v", agent.StateMachine.AbortReason)
+	}
+	if !agent.CurrentActions()[0].IsDone() {
+		t.Error("expected the agent to have chopped the tree")
+	}
+}
+
+func TestNavigator_range(t *testing.T) {
+	agent := newGridAgent(New(10, 10), Point{6, 0})
+	agent.AvailableActions()[0].(*chopAction).SetRange(3)
+
+	// plan, schedule move, three moves, chop
+	for i := 0; i < 6; i++ {
+		agent.Update()
+	}
+
+	if agent.position != (Point{3, 0}) {
+		t.Errorf("expected the agent to stop once in range, it's at %v", agent.position)
+	}
+	if !agent.CurrentActions()[0].IsDone() {
+		t.Error("expected the agent to have chopped the tree")
+	}
+}
+
+func TestNavigator_unreachable(t *testing.T) {
+	g := New(3, 3)
+	for y := 0; y < 3; y++ {
+		g.SetWalkable(Point{1, y}, false)
+	}
+	agent := newGridAgent(g, Point{2, 0})
+
+	for i := 0; i < 3; i++ {
+		agent.Update()
+	}
+
+	if !errors.Is(agent.StateMachine.AbortReason, goap.ErrUnreachable) {
+		t.Errorf("expected the plan to abort as unreachable, got %v", agent.StateMachine.AbortReason)
+	}
+}
+
+func TestNavigator_MoveAgent(t *testing.T) {
+	g := New(5, 5)
+	nav := NewNavigator(g, NoDiagonals)
+	agent := &gridAgent{}
+	action := &testAction{goap.NewAction("go", 1)}
+	action.SetTarget(Point{2, 0})
+
+	if nav.MoveAgent(agent, action) {
+		t.Error("expected the agent to not be there after one step")
+	}
+	if !nav.MoveAgent(agent, action) {
+		t.Error("expected the agent to arrive after two steps")
+	}
+	if agent.position != (Point{2, 0}) {
+		t.Errorf("expected the agent to be at the target, it's at %v", agent.position)
+	}
+}
+
+func TestNavigator_Forget(t *testing.T) {
+	nav := NewNavigator(New(5, 5), NoDiagonals)
+	agent := &gridAgent{}
+	action := &testAction{goap.NewAction("go", 1)}
+	action.SetTarget(Point{3, 0})
+
+	nav.MoveAgent(agent, action)
+	if len(nav.routes) != 1 {
+		t.Fatalf("expected the agent to have a route, got %d", len(nav.routes))
+	}
+	nav.Forget(agent)
+	if len(nav.routes) != 0 {
+		t.Errorf("expected the route to be dropped, got %d", len(nav.routes))
+	}
+	if nav.Move(agent).Status != goap.NavUnreachable {
+		t.Error("expected a forgotten agent to have nowhere to go")
+	}
+}
+
+func TestRangeChecker(t *testing.T) {
+	agent := &gridAgent{position: Point{1, 1}}
+	if !RangeChecker(agent, Point{1, 1}, 0) {
+		t.Error("expected the agent to be in range of its own tile")
+	}
+	if RangeChecker(agent, Point{2, 2}, 1) || !RangeChecker(agent, Point{2, 2}, 1.5) {
+		t.Error("expected a diagonal tile to be further away than 1 and closer than 1.5")
+	}
+	if RangeChecker(&goap.DefaultAgent{}, Point{1, 1}, 0) {
+		t.Error("expected an agent that isn't a Mover to never be in range")
+	}
+}
+
+type testAction struct {
+	goap.DefaultAction
+}
+
+func (a *testAction) Perform(agent goap.Agent) bool {
+	return true
+}
+
+func TestNavigator_TravelCost(t *testing.T) {
+	g := New(5, 5)
+	for y := 0; y < 4; y++ {
+		g.SetWalkable(Point{2, y}, false)
+	}
+	nav := NewNavigator(g, NoDiagonals)
+	agent := &gridAgent{}
+
+	if cost := nav.TravelCost(agent, agent, Point{4, 0}); cost != 12 {
+		t.Errorf("expected the cost to go around the wall to be 12, got %v", cost)
+	}
+
+	g.SetWalkable(Point{2, 4}, false)
+	if cost := nav.TravelCost(agent, agent, Point{4, 0}); !math.IsInf(cost, 1) {
+		t.Errorf("expected an unreachable target to cost infinity, got %v", cost)
+	}
+}
+
+// treeAction lets the planner choose which tree to chop
+type treeAction struct {
+	goap.DefaultAction
+	trees []Point
+}
+
+func (a *treeAction) Targets(agent goap.Agent) []goap.Candidate {
+	return goap.TypedCandidates(a.trees, nil)
+}
+
+func (a *treeAction) Perform(agent goap.Agent) bool {
+	return true
+}
+
+func TestNavigator_TravelCost_walled_off(t *testing.T) {
+	// the tree at {4 0} is close but walled off, the one at {0 4} is reachable
+	g := New(5, 5)
+	g.SetWalkable(Point{3, 0}, false)
+	g.SetWalkable(Point{3, 1}, false)
+	g.SetWalkable(Point{4, 1}, false)
+	nav := NewNavigator(g, NoDiagonals)
+
+	chop := &treeAction{DefaultAction: goap.NewAction("chop", 1), trees: []Point{{4, 0}, {0, 4}}}
+	chop.SetRequiresInRange(true)
+	chop.AddEffect(goap.State{Name: "hasWood", Value: true})
+	goal := goap.StateList{"hasWood": true}
+	agent := &gridAgent{}
+	planner := &goap.Planner{TravelCost: nav.TravelCost}
+
+	plan := planner.Plan(agent, []goap.Action{chop}, make(goap.StateList), goal)
+	if len(plan) != 1 || plan[0].Target() != (Point{0, 4}) {
+		t.Fatalf("expected the reachable tree to be chosen, got %v", plan)
+	}
+
+	chop.trees = chop.trees[:1]
+	if plan := planner.Plan(agent, []goap.Action{chop}, make(goap.StateList), goal); plan != nil {
+		t.Errorf("expected no plan to a walled off tree, got %v with target %v", plan, plan[0].Target())
+	}
+}
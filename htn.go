@@ -0,0 +1,121 @@
+package goap
+
+// Task is a step in a hierarchical task network. It's either an Action, which is a primitive task
+// that is performed as it is, or a CompoundTask that is decomposed into other tasks.
+type Task interface {
+	String() string
+}
+
+// CompoundTask is a task that can be done in several ways. Its methods are tried in order and the
+// first one that leads to a complete plan is used.
+type CompoundTask struct {
+	Name    string
+	Methods []Method
+}
+
+func (t *CompoundTask) String() string {
+	return t.Name
+}
+
+// Method is one way of doing a CompoundTask. It can be used when its preconditions are met, and
+// does its subtasks in order.
+type Method struct {
+	Name          string
+	Preconditions StateList
+	Subtasks      []Task
+}
+
+// PlanHTN decomposes the root task into a sequence of actions, starting in the world state. Each
+// action's preconditions must hold when it's reached, and its effects are applied for the tasks
+// that come after it. It returns nil if no decomposition works.
+//
+// Unlike Plan, the same action can show up more than once in the returned plan, as a separate
+// instance each time.
+func PlanHTN(agent Agent, root Task, worldState StateList) []Action {
+	h := &htn{agent: agent, checked: make(map[Action]bool)}
+	plan, _, ok := h.decompose([]Task{root}, worldState, nil)
+	if !ok {
+		return nil
+	}
+	return plan
+}
+
+// HTNIdle returns an FSM state that plans by decomposing the root task, it can be used in place of
+// Idle, for example with NewFSM(HTNIdle(root)). The agent's goal state is not used, but it's still
+// passed to PlanFailed and PlanFound.
+func HTNIdle(root Task) FSMState {
+	var idle FSMState
+	idle = func(fsm *FSM, agent Agent, debug func(string)) {
+		// come back here, and not to Idle, when the plan is done
+		fsm.idle = idle
+		if !fsm.allowPlan(agent) {
+			debug("HTNIdle - waiting to plan")
+			return
+		}
+		debug("HTNIdle - is planning")
+		goal := agent.GoalState()
+		plan := PlanHTN(agent, root, agent.State())
+		if plan == nil {
+			agent.PlanFailed(goal)
+			return
+		}
+		if !fsm.reserve(agent, plan) {
+			debug("HTNIdle - a target in the plan is reserved")
+			agent.PlanFailed(goal)
+			return
+		}
+		agent.SetCurrentActions(plan)
+		agent.PlanFound(goal, plan)
+		fsm.Reset(Do)
+	}
+	return idle
+}
+
+type htn struct {
+	agent Agent
+	// the result of CheckContextPrecondition for each action, it's only asked once per plan
+	checked map[Action]bool
+}
+
+// decompose the tasks in order and return the plan extended with the actions they became, together
+// with the state after them
+func (h *htn) decompose(tasks []Task, state StateList, plan []Action) ([]Action, StateList, bool) {
+	if len(tasks) == 0 {
+		return plan, state, true
+	}
+	task, rest := tasks[0], tasks[1:]
+
+	switch t := task.(type) {
+	case Action:
+		action := NewInstance(t)
+		action.Reset()
+		if !h.usable(t, action) || !inState(action.Preconditions(), state) {
+			return nil, nil, false
+		}
+		next := append(plan[:len(plan):len(plan)], action)
+		return h.decompose(rest, populateState(state, action.Effects()), next)
+
+	case *CompoundTask:
+		for _, method := range t.Methods {
+			if !inState(method.Preconditions, state) {
+				continue
+			}
+			// try the method's subtasks followed by the rest, and backtrack to the next method if
+			// they don't work out
+			subtasks := append(method.Subtasks[:len(method.Subtasks):len(method.Subtasks)], rest...)
+			if result, end, ok := h.decompose(subtasks, state, plan); ok {
+				return result, end, true
+			}
+		}
+	}
+	return nil, nil, false
+}
+
+func (h *htn) usable(definition, action Action) bool {
+	if ok, found := h.checked[definition]; found {
+		return ok
+	}
+	ok := action.CheckContextPrecondition(h.agent)
+	h.checked[definition] = ok
+	return ok
+}
@@ -0,0 +1,89 @@
+package goap
+
+import (
+	"testing"
+)
+
+func feedDomain(getFood, eat Action) *CompoundTask {
+	return &CompoundTask{
+		Name: "feed",
+		Methods: []Method{
+			{
+				Name:          "eatWhatWeHave",
+				Preconditions: StateList{HaveFood.Name: true},
+				Subtasks:      []Task{eat},
+			},
+			{
+				Name:     "findSomethingToEat",
+				Subtasks: []Task{getFood, eat},
+			},
+		},
+	}
+}
+
+func TestPlanHTN(t *testing.T) {
+	root := feedDomain(findFood(), eatAction())
+
+	state := make(StateList)
+	state.Is(Hungry).Dont(HaveFood)
+
+	plan := PlanHTN(&DefaultAgent{}, root, state)
+	if len(plan) != 2 || plan[0].String() != "getFood" || plan[1].String() != "eat" {
+		t.Errorf("expected to get food and eat it, got %v", plan)
+	}
+
+	state.Add(HaveFood)
+	plan = PlanHTN(&DefaultAgent{}, root, state)
+	if len(plan) != 1 || plan[0].String() != "eat" {
+		t.Errorf("expected to eat the food we have, got %v", plan)
+	}
+}
+
+func TestPlanHTN_backtrack(t *testing.T) {
+	// the first method fits, but eat can't be done in it since we're not hungry
+	root := feedDomain(findFood(), eatAction())
+	nap := &CompoundTask{
+		Name: "rest",
+		Methods: []Method{
+			{Name: "feedFirst", Subtasks: []Task{root}},
+			{Name: "justSleep", Subtasks: []Task{sleepAction()}},
+		},
+	}
+
+	state := make(StateList)
+	state.Isnt(Hungry).Add(HaveFood).Add(Tired)
+
+	plan := PlanHTN(&DefaultAgent{}, nap, state)
+	if len(plan) != 1 || plan[0].String() != "sleep" {
+		t.Errorf("expected to backtrack to sleeping, got %v", plan)
+	}
+
+	state.Isnt(Tired)
+	if plan := PlanHTN(&DefaultAgent{}, nap, state); plan != nil {
+		t.Errorf("expected no plan, got %v", plan)
+	}
+}
+
+func TestHTNIdle(t *testing.T) {
+	getFood := newFlakyAction("getFood", 1, 0)
+	getFood.AddEffect(HaveFood)
+	eat := newFlakyAction("eat", 1, 0)
+	eat.AddPrecondition(HaveFood)
+
+	agent := newRecordingAgent()
+	agent.StateMachine = NewFSM(HTNIdle(feedDomain(getFood, eat)))
+
+	var messages []string
+	for i := 0; i < 5; i++ {
+		agent.FSM(agent, func(m string) {
+			messages = append(messages, m)
+		})
+	}
+
+	if agent.finished != 1 {
+		t.Errorf("expected the plan to finish, got %d", agent.finished)
+	}
+	if last := messages[len(messages)-1]; last != "HTNIdle - is planning" {
+		t.Errorf("expected to go back to HTNIdle when done, got %q", last)
+	}
+}
@@ -0,0 +1,67 @@
+package goap
+
+import (
+	"math/bits"
+)
+
+// actionIndex tells which usable actions of a search are relevant to which state names, so that
+// the search doesn't have to check every action at every node. Actions are known by their position
+// in search.steps and kept in bitsets.
+type actionIndex struct {
+	// the actions with an effect on each state name, by the name's bit
+	producers [][]uint64
+	// for each action, the actions with a precondition on a state name it has an effect on
+	affects [][]uint64
+	// the number of 64 bit words needed for one bit per action
+	words int
+}
+
+func newActionIndex(names *stateIndex, steps []step) *actionIndex {
+	x := &actionIndex{
+		producers: make([][]uint64, len(names.bits)),
+		affects:   make([][]uint64, len(steps)),
+		words:     (len(steps) + 63) / 64,
+	}
+	mentions := make([][]uint64, len(names.bits))
+	for b := range x.producers {
+		x.producers[b] = make([]uint64, x.words)
+		mentions[b] = make([]uint64, x.words)
+	}
+	for i, step := range steps {
+		for _, b := range setBits(step.effects.mask) {
+			setBit(x.producers[b], i)
+		}
+		for _, b := range setBits(step.preconditions.mask) {
+			setBit(mentions[b], i)
+		}
+	}
+	for i, step := range steps {
+		x.affects[i] = make([]uint64, x.words)
+		for _, b := range setBits(step.effects.mask) {
+			for w := range x.affects[i] {
+				x.affects[i][w] |= mentions[b][w]
+			}
+		}
+	}
+	return x
+}
+
+func setBit(set []uint64, i int) {
+	set[i/64] |= 1 << (i % 64)
+}
+
+func clearBit(set []uint64, i int) {
+	set[i/64] &^= 1 << (i % 64)
+}
+
+// setBits returns the positions of the bits that are set, it's only used while preparing a search
+func setBits(set []uint64) []int {
+	var result []int
+	for w, word := range set {
+		for word != 0 {
+			result = append(result, w*64+bits.TrailingZeros64(word))
+			word &= word - 1
+		}
+	}
+	return result
+}
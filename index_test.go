@@ -0,0 +1,25 @@
+package goap
+
+import (
+	"testing"
+)
+
+func TestActionIndex(t *testing.T) {
+	actions := []Action{findFood(), eatAction(), sleepAction()}
+
+	s := &search{Planner: &Planner{}}
+	s.prepare(actions, StateList{Hungry.Name: true}, StateList{Hungry.Name: false})
+
+	producers := setBits(s.actions.producers[s.index.bits[HaveFood.Name]])
+	if len(producers) != 2 || producers[0] != 0 || producers[1] != 1 {
+		t.Errorf("expected getFood and eat to produce %s, got %v", HaveFood.Name, producers)
+	}
+
+	// getFood changes what it and eat need, sleep only changes what it needs itself
+	if affected := setBits(s.actions.affects[0]); len(affected) != 2 || affected[0] != 0 || affected[1] != 1 {
+		t.Errorf("expected getFood to affect getFood and eat, got %v", affected)
+	}
+	if affected := setBits(s.actions.affects[2]); len(affected) != 1 || affected[0] != 2 {
+		t.Errorf("expected sleep to only affect itself, got %v", affected)
+	}
+}
@@ -0,0 +1,86 @@
+package goap
+
+import (
+	"reflect"
+)
+
+// Instancer is implemented by actions that make their own runtime instances, see NewInstance.
+// Instance must return a new action that shares nothing with the definition that is changed during
+// planning or performing.
+type Instancer interface {
+	Instance() Action
+}
+
+// instance is implemented by DefaultAction to remember the definition it was made from
+type instance interface {
+	setDefinition(Action)
+	definitionOf() Action
+	defaultAction() *DefaultAction
+}
+
+// NewInstance returns a runtime instance of an action definition. The planner plans with instances,
+// so that the available actions are never changed by planning and can be shared between agents and
+// planned with concurrently. Done, the target and anything else that is set during planning and
+// performing belongs to the instance.
+//
+// Actions that implement Instancer make their own instance. Otherwise, actions that embed
+// DefaultAction and are pointers to a struct are copied, together with the structs they embed by
+// pointer on the way to DefaultAction. The copy is shallow, so maps, slices and other pointers are
+// shared with the definition and should only be read by Perform and the other methods. Other
+// actions are their own instance.
+func NewInstance(definition Action) Action {
+	if i, ok := definition.(Instancer); ok {
+		action := i.Instance()
+		if d, ok := action.(instance); ok && action != definition {
+			d.setDefinition(Definition(definition))
+		}
+		return action
+	}
+	d, ok := definition.(instance)
+	if !ok {
+		return definition
+	}
+	v := reflect.ValueOf(definition)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return definition
+	}
+	action := copyStruct(v).Interface().(instance)
+	if action.defaultAction() == d.defaultAction() {
+		// the DefaultAction is embedded through a pointer that can't be copied
+		return definition
+	}
+	action.setDefinition(Definition(definition))
+	return action.(Action)
+}
+
+// copyStruct copies the struct that v points to, and the structs it embeds by pointer
+func copyStruct(v reflect.Value) reflect.Value {
+	c := reflect.New(v.Elem().Type())
+	c.Elem().Set(v.Elem())
+	copyEmbedded(c.Elem())
+	return c
+}
+
+func copyEmbedded(s reflect.Value) {
+	for i := 0; i < s.NumField(); i++ {
+		if !s.Type().Field(i).Anonymous {
+			continue
+		}
+		field := s.Field(i)
+		switch {
+		case field.Kind() == reflect.Struct:
+			copyEmbedded(field)
+		case field.Kind() == reflect.Ptr && !field.IsNil() && field.Elem().Kind() == reflect.Struct && field.CanSet():
+			field.Set(copyStruct(field))
+		}
+	}
+}
+
+// Definition returns the action definition that an instance was made from with NewInstance, or the
+// action itself if it isn't an instance.
+func Definition(action Action) Action {
+	if i, ok := action.(instance); ok && i.definitionOf() != nil {
+		return i.definitionOf()
+	}
+	return action
+}
@@ -0,0 +1,89 @@
+package goap
+
+import (
+	"sync"
+	"testing"
+)
+
+// sharedAction embeds its DefaultAction by pointer
+type sharedAction struct {
+	*DefaultAction
+}
+
+func (a *sharedAction) Perform(agent Agent) bool {
+	return true
+}
+
+func TestNewInstance(t *testing.T) {
+	fetch := newFetchAction("fetch", 2, Candidate{"apple", 1})
+	fetch.AddEffect(HaveFood)
+
+	instance := NewInstance(fetch)
+	if instance == Action(fetch) {
+		t.Fatal("expected the instance to be a copy of the definition")
+	}
+	instance.SetTarget("apple")
+	if fetch.Target() != nil {
+		t.Errorf("expected the definition to keep its target, got %v", fetch.Target())
+	}
+	if Definition(instance) != fetch {
+		t.Errorf("expected the instance to know its definition, got %v", Definition(instance))
+	}
+	if Definition(NewInstance(instance)) != fetch {
+		t.Error("expected an instance of an instance to have the same definition")
+	}
+
+	d := NewAction("shared", 1)
+	shared := &sharedAction{DefaultAction: &d}
+	instance = NewInstance(shared)
+	instance.SetTarget("apple")
+	if instance == Action(shared) || shared.Target() != nil {
+		t.Errorf("expected a DefaultAction embedded by pointer to be copied, got target %v", shared.Target())
+	}
+}
+
+func TestPlan_does_not_change_actions(t *testing.T) {
+	fetch := newFetchAction("fetch", 2, Candidate{"far apple", 10}, Candidate{"near apple", 1})
+	fetch.AddEffect(HaveFood)
+	fetch.Done = true
+
+	goal := make(StateList)
+	goal.Add(HaveFood)
+
+	actionList := Plan(&DefaultAgent{}, []Action{fetch}, make(StateList), goal)
+	if len(actionList) != 1 || actionList[0].Target() != "near apple" {
+		t.Fatalf("expected fetch to be planned with the near apple, got %v", actionList)
+	}
+	if fetch.Target() != nil || !fetch.Done {
+		t.Errorf("expected the definition to be left alone, got target %v and done %v", fetch.Target(), fetch.Done)
+	}
+}
+
+func TestPlan_concurrent(t *testing.T) {
+	fetch := newFetchAction("fetch", 2, Candidate{"far apple", 10}, Candidate{"near apple", 1})
+	fetch.AddEffect(HaveFood)
+	fetch.AddPrecondition(Dont(HaveFood))
+	actions := []Action{fetch, findFood(), eatAction(), sleepAction()}
+	planner := &Planner{Cooldowns: NewCooldowns(1)}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			state := make(StateList)
+			state.Is(Hungry).Dont(HaveFood)
+			goal := make(StateList)
+			goal.Isnt(Hungry)
+			for j := 0; j < 20; j++ {
+				plan := planner.Plan(&DefaultAgent{}, actions, state, goal)
+				if len(plan) != 2 || plan[0].Target() != "near apple" {
+					t.Errorf("expected fetch and eat, got %v", plan)
+					return
+				}
+				plan[0].Perform(nil)
+			}
+		}()
+	}
+	wg.Wait()
+}
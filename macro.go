@@ -0,0 +1,100 @@
+package goap
+
+import (
+	"fmt"
+)
+
+// NewMacroAction creates an action made of other actions, that the planner treats as a single step.
+// Its preconditions are the preconditions of the actions that aren't met by the actions before
+// them, its effects are the combined effects of all actions, and its cost and duration are their
+// sums. It returns an error if an action needs a state that an earlier action undoes.
+func NewMacroAction(name string, actions ...Action) (*MacroAction, error) {
+	m := &MacroAction{
+		DefaultAction: NewAction(name, 0),
+		actions:       actions,
+	}
+	for _, action := range actions {
+		for key, value := range action.Preconditions() {
+			if effect, found := m.effects[key]; found {
+				if effect != value {
+					return nil, fmt.Errorf("goap: macro %s: %s needs %s to be %t, but it's set to %t before it", name, action, key, value, effect)
+				}
+				continue
+			}
+			if pre, found := m.preconditions[key]; found && pre != value {
+				return nil, fmt.Errorf("goap: macro %s: %s needs %s to be %t, but it must also be %t", name, action, key, value, pre)
+			}
+			m.preconditions[key] = value
+		}
+		for key, value := range action.Effects() {
+			m.effects[key] = value
+		}
+		m.cost += action.Cost()
+		m.duration += action.Duration()
+	}
+	return m, nil
+}
+
+// MacroAction is a composite action, see NewMacroAction. When the FSM gets to a MacroAction in the
+// plan it replaces it with its actions, so that they are moved to and performed one by one, just
+// like the rest of the plan.
+//
+// Actions of the macro that need a target are bound to their cheapest candidate when the macro is
+// planned, and the cost of the candidate is added to the macro. The macro is left out of planning
+// if one of them has no candidates.
+type MacroAction struct {
+	DefaultAction
+	actions []Action
+}
+
+// Instance makes an instance of the macro with instances of its actions.
+func (m *MacroAction) Instance() Action {
+	i := *m
+	i.actions = make([]Action, len(m.actions))
+	for j, action := range m.actions {
+		i.actions[j] = NewInstance(action)
+	}
+	return &i
+}
+
+// Actions returns the actions that the macro is made of, in order.
+func (m *MacroAction) Actions() []Action {
+	return m.actions
+}
+
+func (m *MacroAction) Reset() {
+	m.DefaultAction.Reset()
+	for _, action := range m.actions {
+		action.Reset()
+	}
+}
+
+// CheckContextPrecondition is true if it's true for all actions.
+func (m *MacroAction) CheckContextPrecondition(agent Agent) bool {
+	for _, action := range m.actions {
+		if !action.CheckContextPrecondition(agent) {
+			return false
+		}
+	}
+	return true
+}
+
+// Perform the first action that isn't done. It's only used when a macro is run outside of the FSM,
+// and doesn't move the agent into range of the action's target.
+func (m *MacroAction) Perform(agent Agent) bool {
+	for _, action := range m.actions {
+		if !action.IsDone() {
+			return action.Perform(agent)
+		}
+	}
+	return true
+}
+
+func (m *MacroAction) IsDone() bool {
+	for _, action := range m.actions {
+		if !action.IsDone() {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,103 @@
+package goap
+
+import (
+	"testing"
+)
+
+func TestNewMacroAction(t *testing.T) {
+	getWeapon := newTestAction("getWeapon", 2, false)
+	getWeapon.AddEffect(State{"hasWeapon", true})
+	load := newTestAction("load", 1, false)
+	load.AddPrecondition(State{"hasWeapon", true}, State{"hasAmmo", true})
+	load.AddEffect(State{"loaded", true}, State{"hasAmmo", false})
+	aim := newTestAction("aim", 1, false)
+	aim.AddPrecondition(State{"loaded", true})
+	aim.AddEffect(State{"aiming", true})
+
+	macro, err := NewMacroAction("getReady", getWeapon, load, aim)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := StateList{"hasAmmo": true}
+	if len(macro.Preconditions()) != 1 || !inState(expected, macro.Preconditions()) {
+		t.Errorf("expected the preconditions %v, got %v", expected, macro.Preconditions())
+	}
+	expected = StateList{"hasWeapon": true, "loaded": true, "hasAmmo": false, "aiming": true}
+	if len(macro.Effects()) != 4 || !inState(expected, macro.Effects()) {
+		t.Errorf("expected the effects %v, got %v", expected, macro.Effects())
+	}
+	if macro.Cost() != 4 {
+		t.Errorf("expected the cost to be 4, got %v", macro.Cost())
+	}
+}
+
+func TestNewMacroAction_conflict(t *testing.T) {
+	eat := eatAction()
+	_, err := NewMacroAction("eatTwice", eat, eatAction())
+	if err == nil {
+		t.Error("expected an error when an action undoes the precondition of a later action")
+	}
+}
+
+func TestMacroAction_Do(t *testing.T) {
+	getFood := newFlakyAction("getFood", 1, 0)
+	getFood.AddPrecondition(Dont(HaveFood))
+	getFood.AddEffect(HaveFood)
+	eat := newFlakyAction("eat", 1, 0)
+	eat.AddPrecondition(HaveFood)
+	eat.AddEffect(Isnt(Hungry))
+
+	macro, err := NewMacroAction("getFoodAndEat", getFood, eat)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	agent := newRecordingAgent(macro)
+	agent.AddState(Dont(HaveFood))
+	goal := make(StateList)
+	goal.Isnt(Hungry)
+	agent.SetGoalState(goal)
+
+	// plan, perform getFood, perform eat, finish
+	for i := 0; i < 4; i++ {
+		agent.Update()
+	}
+
+	if *getFood.performs != 1 || *eat.performs != 1 {
+		t.Errorf("expected both actions to be performed once, got %d and %d", *getFood.performs, *eat.performs)
+	}
+	if agent.finished != 1 {
+		t.Errorf("expected the plan to finish, got %d", agent.finished)
+	}
+}
+
+func TestMacroAction_targets(t *testing.T) {
+	fetch := newFetchAction("fetch", 1, Candidate{"far apple", 10}, Candidate{"near apple", 1})
+	fetch.AddPrecondition(Dont(HaveFood))
+	fetch.AddEffect(HaveFood)
+
+	macro, err := NewMacroAction("fetchAndEat", fetch, eatAction())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	currentState := make(StateList)
+	currentState.Is(Hungry).Dont(HaveFood)
+	goal := make(StateList)
+	goal.Isnt(Hungry)
+
+	actionList := Plan(&DefaultAgent{}, []Action{macro}, currentState, goal)
+	if len(actionList) != 1 {
+		t.Fatalf("expected the macro to be planned, got %v", actionList)
+	}
+	if target := actionList[0].(*MacroAction).Actions()[0].Target(); target != "near apple" {
+		t.Errorf("expected fetch to be bound to the near apple, got %v", target)
+	}
+
+	// without candidates fetch can't be moved to, so neither can the macro
+	fetch.targets = nil
+	if actionList := Plan(&DefaultAgent{}, []Action{macro}, currentState, goal); actionList != nil {
+		t.Errorf("expected no plan when an action of the macro has no target, got %v", actionList)
+	}
+}
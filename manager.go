@@ -0,0 +1,273 @@
+package goap
+
+import (
+	"container/heap"
+	"time"
+)
+
+// PlanScheduler decides when agents get to plan. Idle and the other planning states ask it before
+// they plan, see FSM.Scheduler.
+type PlanScheduler interface {
+	// AllowPlan returns true if the agent may plan now. If it returns false, the agent asks again
+	// on its next update.
+	AllowPlan(agent Agent) bool
+}
+
+// NewAgentManager creates a manager that lets at most plansPerTick agents plan on each tick.
+func NewAgentManager(plansPerTick int) *AgentManager {
+	return &AgentManager{
+		PlansPerTick: plansPerTick,
+		agents:       make(map[Agent]*managedAgent),
+	}
+}
+
+// AgentManager updates many agents and spreads their planning over several ticks. Agents that want
+// to plan are queued by priority, and only PlansPerTick of them get to plan on each tick. Distant
+// agents can be updated less often with SetUpdateInterval.
+//
+// The manager is the PlanScheduler of the agents it manages. It's set on their FSM when they are
+// added, if they have a Machine() *FSM method like DefaultAgent, otherwise it has to be set by hand.
+type AgentManager struct {
+	// How many agents may plan per tick, zero means no limit.
+	PlansPerTick int
+	// Reservations, if set, is ticked on every tick, and agents that are removed release their
+	// reservations.
+	Reservations *Reservations
+	// Cooldowns, if set, is ticked on every tick together with the Cooldowns of the planners of
+	// the managed agents. Each is ticked once per tick, however many agents share it.
+	Cooldowns *Cooldowns
+
+	tick   int
+	order  []*managedAgent
+	agents map[Agent]*managedAgent
+	queue  planQueue
+	// counts the agents that were queued so that equal priorities are served first come first
+	queued int
+}
+
+type managedAgent struct {
+	agent    Agent
+	priority int
+	interval int
+	offset   int
+
+	// waiting in the queue, and at what position in it
+	waiting bool
+	index   int
+	seq     int
+	// allowed to plan on its next update
+	granted bool
+}
+
+// TickStats is the timing and the work done during one tick of an AgentManager.
+type TickStats struct {
+	Tick int
+	// Number of agents that were updated.
+	Updated int
+	// Number of agents that planned.
+	Planned int
+	// Number of agents still waiting to plan.
+	Waiting int
+	// Time spent updating the agents, and the part of that spent by the agents that planned.
+	Duration     time.Duration
+	PlanDuration time.Duration
+}
+
+type fsmAgent interface {
+	Machine() *FSM
+}
+
+// Add starts managing the agent. Agents with a higher priority get to plan before those with a
+// lower one.
+func (m *AgentManager) Add(agent Agent, priority int) {
+	if _, found := m.agents[agent]; found {
+		m.SetPriority(agent, priority)
+		return
+	}
+	ma := &managedAgent{agent: agent, priority: priority, interval: 1}
+	m.agents[agent] = ma
+	m.order = append(m.order, ma)
+	if a, ok := agent.(fsmAgent); ok && a.Machine() != nil {
+		a.Machine().Scheduler = m
+	}
+}
+
+// Remove stops managing the agent and releases its reservations.
+func (m *AgentManager) Remove(agent Agent) {
+	ma, found := m.agents[agent]
+	if !found {
+		return
+	}
+	if ma.waiting {
+		heap.Remove(&m.queue, ma.index)
+	}
+	delete(m.agents, agent)
+	for i, o := range m.order {
+		if o == ma {
+			m.order = append(m.order[:i], m.order[i+1:]...)
+			break
+		}
+	}
+	if a, ok := agent.(fsmAgent); ok && a.Machine() != nil && a.Machine().Scheduler == m {
+		a.Machine().Scheduler = nil
+	}
+	if m.Reservations != nil {
+		m.Reservations.ReleaseAll(agent)
+	}
+}
+
+// Len returns the number of managed agents.
+func (m *AgentManager) Len() int {
+	return len(m.order)
+}
+
+// SetPriority changes the planning priority of the agent.
+func (m *AgentManager) SetPriority(agent Agent, priority int) {
+	if ma, found := m.agents[agent]; found {
+		ma.priority = priority
+		if ma.waiting {
+			heap.Fix(&m.queue, ma.index)
+		}
+	}
+}
+
+// SetUpdateInterval makes the agent update only every interval ticks, for agents that are far away
+// and don't need to be as responsive. Agents with the same interval are spread out over the ticks.
+func (m *AgentManager) SetUpdateInterval(agent Agent, interval int) {
+	ma, found := m.agents[agent]
+	if !found {
+		return
+	}
+	if interval < 1 {
+		interval = 1
+	}
+	ma.interval = interval
+	ma.offset = 0
+	for _, o := range m.order {
+		if o == ma {
+			break
+		}
+		if o.interval == interval {
+			ma.offset++
+		}
+	}
+	ma.offset %= interval
+}
+
+// AllowPlan implements PlanScheduler. Agents that aren't allowed to plan yet are queued.
+func (m *AgentManager) AllowPlan(agent Agent) bool {
+	ma, found := m.agents[agent]
+	if !found || m.PlansPerTick <= 0 {
+		return true
+	}
+	if ma.granted {
+		ma.granted = false
+		return true
+	}
+	if !ma.waiting {
+		m.queued++
+		ma.seq = m.queued
+		heap.Push(&m.queue, ma)
+	}
+	return false
+}
+
+// tickCooldowns ticks the cooldowns of the manager and of the agents, once each
+func (m *AgentManager) tickCooldowns() {
+	ticked := make(map[*Cooldowns]bool)
+	tick := func(c *Cooldowns) {
+		if c != nil && !ticked[c] {
+			ticked[c] = true
+			c.Tick()
+		}
+	}
+	tick(m.Cooldowns)
+	for _, ma := range m.order {
+		if a, ok := ma.agent.(fsmAgent); ok && a.Machine() != nil {
+			tick(a.Machine().cooldowns())
+		}
+	}
+}
+
+// Tick updates the agents that are due this tick, after letting the agents at the front of the
+// queue plan.
+func (m *AgentManager) Tick() TickStats {
+	m.tick++
+	stats := TickStats{Tick: m.tick}
+	if m.Reservations != nil {
+		m.Reservations.Tick()
+	}
+	m.tickCooldowns()
+
+	// agents that aren't updated this tick keep their place in the queue
+	var skipped []*managedAgent
+	for granted := 0; m.queue.Len() > 0 && granted < m.PlansPerTick; {
+		ma := heap.Pop(&m.queue).(*managedAgent)
+		if !m.due(ma) {
+			skipped = append(skipped, ma)
+			continue
+		}
+		ma.granted = true
+		granted++
+	}
+	for _, ma := range skipped {
+		heap.Push(&m.queue, ma)
+	}
+
+	start := time.Now()
+	for _, ma := range m.order {
+		if !m.due(ma) {
+			continue
+		}
+		granted := ma.granted
+		updateStart := time.Now()
+		ma.agent.Update()
+		stats.Updated++
+		if granted && !ma.granted {
+			stats.Planned++
+			stats.PlanDuration += time.Since(updateStart)
+		}
+		// the agent didn't need to plan after all
+		ma.granted = false
+	}
+	stats.Duration = time.Since(start)
+	stats.Waiting = m.queue.Len()
+	return stats
+}
+
+func (m *AgentManager) due(ma *managedAgent) bool {
+	return m.tick%ma.interval == ma.offset
+}
+
+// planQueue is a priority queue of the agents waiting to plan
+type planQueue []*managedAgent
+
+func (q planQueue) Len() int { return len(q) }
+
+func (q planQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+	return q[i].seq < q[j].seq
+}
+
+func (q planQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index = i
+	q[j].index = j
+}
+
+func (q *planQueue) Push(x interface{}) {
+	ma := x.(*managedAgent)
+	ma.index = len(*q)
+	ma.waiting = true
+	*q = append(*q, ma)
+}
+
+func (q *planQueue) Pop() interface{} {
+	old := *q
+	ma := old[len(old)-1]
+	ma.waiting = false
+	*q = old[:len(old)-1]
+	return ma
+}
@@ -0,0 +1,109 @@
+package goap
+
+import (
+	"testing"
+)
+
+func newManagedAgent() *recordingAgent {
+	sleep := newFlakyAction("sleep", 1, 0)
+	sleep.AddEffect(Isnt(Tired))
+	agent := newRecordingAgent(sleep)
+	goal := make(StateList)
+	goal.Isnt(Tired)
+	agent.SetGoalState(goal)
+	return agent
+}
+
+func TestAgentManager_PlansPerTick(t *testing.T) {
+	m := NewAgentManager(2)
+	for i := 0; i < 5; i++ {
+		m.Add(newManagedAgent(), 0)
+	}
+
+	// everyone asks to plan on the first tick and gets queued
+	stats := m.Tick()
+	if stats.Updated != 5 || stats.Planned != 0 || stats.Waiting != 5 {
+		t.Errorf("expected all agents to be queued, got %+v", stats)
+	}
+
+	stats = m.Tick()
+	if stats.Planned != 2 || stats.Waiting != 3 {
+		t.Errorf("expected two agents to plan, got %+v", stats)
+	}
+
+	m.Tick()
+	stats = m.Tick()
+	if stats.Planned != 1 || stats.Waiting != 0 {
+		t.Errorf("expected the last agent to plan, got %+v", stats)
+	}
+}
+
+func TestAgentManager_priority(t *testing.T) {
+	m := NewAgentManager(1)
+	low := newManagedAgent()
+	high := newManagedAgent()
+	m.Add(low, 0)
+	m.Add(high, 10)
+
+	m.Tick()
+	m.Tick()
+	if len(high.CurrentActions()) != 1 || len(low.CurrentActions()) != 0 {
+		t.Error("expected the high priority agent to plan first")
+	}
+}
+
+func TestAgentManager_SetUpdateInterval(t *testing.T) {
+	m := NewAgentManager(0)
+	near := newManagedAgent()
+	far := newManagedAgent()
+	m.Add(near, 0)
+	m.Add(far, 0)
+	m.SetUpdateInterval(far, 3)
+
+	updated := 0
+	for i := 0; i < 6; i++ {
+		updated += m.Tick().Updated
+	}
+	if updated != 8 {
+		t.Errorf("expected 6 updates of near and 2 of far, got %d", updated)
+	}
+}
+
+func TestAgentManager_Remove(t *testing.T) {
+	m := NewAgentManager(1)
+	m.Reservations = NewReservations(0)
+	agent := newManagedAgent()
+	m.Add(agent, 0)
+	m.Reservations.Reserve("bed", agent)
+
+	m.Tick()
+	m.Remove(agent)
+
+	if m.Len() != 0 || m.queue.Len() != 0 {
+		t.Error("expected the agent to be removed")
+	}
+	if agent.StateMachine.Scheduler != nil {
+		t.Error("expected the scheduler to be removed from the agent")
+	}
+	if !m.Reservations.Available("bed", nil) {
+		t.Error("expected the agent's reservations to be released")
+	}
+}
+
+func TestAgentManager_Cooldowns(t *testing.T) {
+	planner := &Planner{Cooldowns: NewCooldowns(0)}
+	m := NewAgentManager(0)
+	for i := 0; i < 3; i++ {
+		agent := newManagedAgent()
+		agent.StateMachine.Planner = planner
+		m.Add(agent, 0)
+	}
+
+	eat := eatAction()
+	planner.Cooldowns.Start(eat, nil, 5)
+	m.Tick()
+	// the shared cooldowns advance once per tick, not once per agent
+	if remaining := planner.Cooldowns.Remaining(eat, nil); remaining != 4 {
+		t.Errorf("expected 4 updates left, got %d", remaining)
+	}
+}
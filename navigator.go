@@ -0,0 +1,51 @@
+package goap
+
+import (
+	"errors"
+)
+
+// Reasons for a plan to be aborted, see FSM.AbortReason.
+var (
+	ErrActionFailed = errors.New("action failed")
+	ErrUnreachable  = errors.New("target is unreachable")
+	ErrStuck        = errors.New("agent is stuck")
+	ErrNoTarget     = errors.New("action requires a target but has none")
+)
+
+// NavStatus tells the MoveTo state how moving towards a target is going.
+type NavStatus int
+
+const (
+	// NavMoving means the agent is on its way to the target.
+	NavMoving NavStatus = iota
+	// NavArrived means the agent has reached the target.
+	NavArrived
+	// NavUnreachable means the target can no longer be reached, for example because the path got
+	// blocked.
+	NavUnreachable
+)
+
+// NavProgress is the result of moving an agent one update along its path.
+type NavProgress struct {
+	Status NavStatus
+	// How far the agent has left to go. It's used to detect an agent that is stuck, and doesn't
+	// have to be exact as long as it gets smaller while the agent gets closer.
+	Remaining float64
+}
+
+// Navigator moves agents to the targets of their actions. When set on the FSM, the MoveTo state
+// requests a path once and then calls Move on every update until the agent arrives, or the plan is
+// aborted because the target is unreachable or the agent is stuck.
+//
+// Navigators that keep a path for each agent can implement Forget(agent Agent) as well, it's
+// called when a plan is aborted, or when the agent gets in range before the end of its path, so
+// that the path isn't kept for an agent that stopped moving. Navigators can implement
+// RequestPathInRange(agent Agent, target interface{}, radius float64) bool to be given the Range
+// of actions that require range, instead of being asked for a path onto the target.
+type Navigator interface {
+	// RequestPath finds a path for the agent to the target. It returns false if there is none.
+	RequestPath(agent Agent, target interface{}) bool
+
+	// Move the agent along the path it was given by RequestPath.
+	Move(agent Agent) NavProgress
+}
@@ -0,0 +1,170 @@
+package goap
+
+import (
+	"errors"
+	"testing"
+)
+
+// scriptedNavigator returns the progress it was given, one per Move
+type scriptedNavigator struct {
+	reachable bool
+	progress  []NavProgress
+	requests  int
+	arrived   bool
+	forgotten int
+}
+
+func (n *scriptedNavigator) Forget(agent Agent) {
+	n.forgotten++
+}
+
+func (n *scriptedNavigator) RequestPath(agent Agent, target interface{}) bool {
+	n.requests++
+	return n.reachable
+}
+
+func (n *scriptedNavigator) Move(agent Agent) NavProgress {
+	p := n.progress[0]
+	n.arrived = p.Status == NavArrived
+	if len(n.progress) > 1 {
+		n.progress = n.progress[1:]
+	}
+	return p
+}
+
+func newNavigatingAgent(nav *scriptedNavigator) (*recordingAgent, *testAction) {
+	chop := newTestAction("chop", 1, true)
+	chop.SetTarget(Vector{10, 0, 0})
+	chop.SetRangeChecker(func(Agent, interface{}, float64) bool {
+		return nav.arrived
+	})
+	chop.AddEffect(State{"hasWood", true})
+
+	agent := newRecordingAgent()
+	agent.SetCurrentActions([]Action{chop})
+	agent.StateMachine.Navigator = nav
+	agent.StateMachine.Reset(Do)
+	return agent, chop
+}
+
+func TestMoveTo_Navigator_arrives(t *testing.T) {
+	nav := &scriptedNavigator{
+		reachable: true,
+		progress:  []NavProgress{{NavMoving, 2}, {NavMoving, 1}, {NavArrived, 0}},
+	}
+	agent, _ := newNavigatingAgent(nav)
+
+	// schedule move and three moves
+	for i := 0; i < 4; i++ {
+		agent.Update()
+	}
+	if nav.requests != 1 {
+		t.Errorf("expected one path request, got %d", nav.requests)
+	}
+	if len(agent.StateMachine.stateStack) != 1 {
+		t.Errorf("expected MoveTo to be done, stack has %d states", len(agent.StateMachine.stateStack))
+	}
+	if len(agent.aborted) != 0 {
+		t.Errorf("expected no aborts, got %v", agent.aborted)
+	}
+}
+
+func TestMoveTo_Navigator_unreachable(t *testing.T) {
+	agent, chop := newNavigatingAgent(&scriptedNavigator{reachable: false})
+
+	agent.Update()
+	agent.Update()
+
+	if len(agent.aborted) != 1 || agent.aborted[0] != chop {
+		t.Fatalf("expected chop to abort the plan, got %v", agent.aborted)
+	}
+	if !errors.Is(agent.StateMachine.AbortReason, ErrUnreachable) {
+		t.Errorf("expected the reason to be ErrUnreachable, got %v", agent.StateMachine.AbortReason)
+	}
+}
+
+func TestMoveTo_Navigator_arrived_out_of_range(t *testing.T) {
+	nav := &scriptedNavigator{reachable: true, progress: []NavProgress{{NavArrived, 0}}}
+	agent, chop := newNavigatingAgent(nav)
+	chop.SetRangeChecker(nil)
+
+	agent.Update()
+	agent.Update()
+
+	if !errors.Is(agent.StateMachine.AbortReason, ErrUnreachable) {
+		t.Errorf("expected the plan to abort instead of moving again, got %v", agent.StateMachine.AbortReason)
+	}
+}
+
+func TestMoveTo_Navigator_in_range(t *testing.T) {
+	nav := &scriptedNavigator{
+		reachable: true,
+		progress:  []NavProgress{{NavMoving, 2}, {NavMoving, 1}, {NavArrived, 0}},
+	}
+	agent, chop := newNavigatingAgent(nav)
+	moves := 0
+	chop.SetRangeChecker(func(Agent, interface{}, float64) bool {
+		return moves >= 1
+	})
+
+	// schedule move and one move
+	agent.Update()
+	moves++
+	agent.Update()
+
+	if len(agent.StateMachine.stateStack) != 1 {
+		t.Errorf("expected MoveTo to stop once in range, stack has %d states", len(agent.StateMachine.stateStack))
+	}
+	if nav.forgotten != 1 {
+		t.Errorf("expected the rest of the path to be forgotten, got %d", nav.forgotten)
+	}
+	if len(agent.aborted) != 0 {
+		t.Errorf("expected no aborts, got %v", agent.aborted)
+	}
+}
+
+func TestMoveTo_no_target(t *testing.T) {
+	chop := newTestAction("chop", 1, true)
+	agent := newRecordingAgent()
+	agent.SetCurrentActions([]Action{chop})
+	agent.StateMachine.Planner = &Planner{Reservations: NewReservations(0)}
+	agent.StateMachine.Planner.Reservations.Reserve("tree", agent)
+	agent.StateMachine.Reset(Do)
+
+	agent.Update()
+	agent.Update()
+
+	if len(agent.aborted) != 1 || agent.aborted[0] != chop {
+		t.Fatalf("expected chop to abort the plan, got %v", agent.aborted)
+	}
+	if !errors.Is(agent.StateMachine.AbortReason, ErrNoTarget) {
+		t.Errorf("expected the reason to be ErrNoTarget, got %v", agent.StateMachine.AbortReason)
+	}
+	if !agent.StateMachine.Planner.Reservations.Available("tree", newRecordingAgent()) {
+		t.Error("expected the reservations to be released")
+	}
+}
+
+func TestMoveTo_Navigator_stuck(t *testing.T) {
+	nav := &scriptedNavigator{
+		reachable: true,
+		progress:  []NavProgress{{NavMoving, 5}, {NavMoving, 4}, {NavMoving, 4}},
+	}
+	agent, _ := newNavigatingAgent(nav)
+	agent.StateMachine.StuckLimit = 3
+
+	for i := 0; i < 5; i++ {
+		agent.Update()
+	}
+	if len(agent.aborted) != 0 {
+		t.Fatalf("expected not to be stuck yet, got %v", agent.StateMachine.AbortReason)
+	}
+
+	agent.Update()
+	if !errors.Is(agent.StateMachine.AbortReason, ErrStuck) {
+		t.Errorf("expected the reason to be ErrStuck, got %v", agent.StateMachine.AbortReason)
+	}
+	if nav.forgotten != 1 {
+		t.Errorf("expected the navigator to forget the path once, got %d", nav.forgotten)
+	}
+}
@@ -0,0 +1,290 @@
+package goap
+
+import (
+	"fmt"
+)
+
+// Link is a causal link in a PartialPlan, the action From sets Key to the value that the action To
+// needs.
+type Link struct {
+	From int
+	To   int
+	Key  string
+}
+
+// PartialPlan is a plan where the actions are only ordered where they depend on each other. It's a
+// directed acyclic graph, actions that aren't ordered can be performed at the same time.
+type PartialPlan struct {
+	// The actions in the order they were planned in, which is always a valid order to do them.
+	Actions []Action
+	// Causal links between the actions.
+	Links []Link
+	// After[i] are the actions that must be done before Actions[i] can start.
+	After [][]int
+}
+
+// NewPartialPlan relaxes the order of a plan from Plan. Two actions keep their order if one of them
+// changes a state that the other needs or changes too, the other actions are independent of each
+// other.
+func NewPartialPlan(plan []Action) *PartialPlan {
+	p := &PartialPlan{
+		Actions: plan,
+		After:   make([][]int, len(plan)),
+	}
+
+	// before[i][j] is true if i has to be done before j
+	before := make([][]bool, len(plan))
+	for i := range plan {
+		before[i] = make([]bool, len(plan))
+	}
+
+	for j, later := range plan {
+		for key, value := range later.Preconditions() {
+			// link to the last action before this one that sets what it needs
+			for i := j - 1; i >= 0; i-- {
+				if effect, found := plan[i].Effects()[key]; found {
+					if effect == value {
+						p.Links = append(p.Links, Link{From: i, To: j, Key: key})
+					}
+					break
+				}
+			}
+		}
+		for i := 0; i < j; i++ {
+			before[i][j] = interferes(plan[i], later)
+		}
+	}
+
+	// only keep the direct predecessors, i before j isn't needed if i is before k and k before j
+	reach := closure(before)
+	for j := range plan {
+		for i := 0; i < j; i++ {
+			if !before[i][j] || indirect(before, reach, i, j) {
+				continue
+			}
+			p.After[j] = append(p.After[j], i)
+		}
+	}
+	return p
+}
+
+// Independent returns true if the actions i and j can be performed at the same time.
+func (p *PartialPlan) Independent(i, j int) bool {
+	lo, hi := i, j
+	if lo > hi {
+		lo, hi = hi, lo
+	}
+	return lo != hi && !p.precedes(lo, hi)
+}
+
+func (p *PartialPlan) precedes(i, j int) bool {
+	for _, k := range p.After[j] {
+		if k == i || p.precedes(i, k) {
+			return true
+		}
+	}
+	return false
+}
+
+// interferes returns true if a and b touch the same state in a way that makes their order matter
+func interferes(a, b Action) bool {
+	for key := range a.Effects() {
+		if _, found := b.Preconditions()[key]; found {
+			return true
+		}
+		if _, found := b.Effects()[key]; found {
+			return true
+		}
+	}
+	for key := range b.Effects() {
+		if _, found := a.Preconditions()[key]; found {
+			return true
+		}
+	}
+	return false
+}
+
+// closure returns reach, where reach[i][j] is true if there is a path from i to j. Actions are only
+// ever before later ones, so the paths to j are complete once the actions before it are done.
+func closure(before [][]bool) [][]bool {
+	reach := make([][]bool, len(before))
+	for i := range before {
+		reach[i] = make([]bool, len(before))
+	}
+	for j := range before {
+		for i := j - 1; i >= 0; i-- {
+			if before[i][j] {
+				reach[i][j] = true
+				continue
+			}
+			for k := i + 1; k < j; k++ {
+				if before[i][k] && reach[k][j] {
+					reach[i][j] = true
+					break
+				}
+			}
+		}
+	}
+	return reach
+}
+
+// indirect returns true if there is a path from i to j through another action
+func indirect(before, reach [][]bool, i, j int) bool {
+	for k := i + 1; k < j; k++ {
+		if before[i][k] && reach[k][j] {
+			return true
+		}
+	}
+	return false
+}
+
+// ParallelIdle is an FSM state that plans like Idle, but performs the plan with ParallelDo so that
+// independent actions run at the same time. Use it with NewFSM(ParallelIdle).
+func ParallelIdle(fsm *FSM, agent Agent, debug func(string)) {
+	fsm.idle = ParallelIdle
+	if !fsm.allowPlan(agent) {
+		debug("ParallelIdle - waiting to plan")
+		return
+	}
+	debug("ParallelIdle - is planning")
+	goal := agent.GoalState()
+	plan := fsm.planner().Plan(agent, agent.AvailableActions(), agent.State(), goal)
+	if plan == nil {
+		agent.PlanFailed(goal)
+		return
+	}
+	if !fsm.reserve(agent, plan) {
+		debug("ParallelIdle - a target in the plan is reserved")
+		agent.PlanFailed(goal)
+		return
+	}
+	// macro actions are replaced with the actions they are made of, like Do does
+	actions := expandMacros(plan)
+	agent.SetCurrentActions(actions)
+	agent.PlanFound(goal, plan)
+	fsm.Reset(ParallelDo)
+	fsm.partial = NewPartialPlan(actions)
+	fsm.finished = make([]bool, len(actions))
+	fsm.retries = make([]retryState, len(actions))
+}
+
+// expandMacros returns the plan with the macro actions in it replaced by their actions
+func expandMacros(plan []Action) []Action {
+	var actions []Action
+	for _, action := range plan {
+		if macro, ok := action.(*MacroAction); ok {
+			actions = append(actions, expandMacros(macro.Actions())...)
+			continue
+		}
+		actions = append(actions, action)
+	}
+	return actions
+}
+
+// ParallelDo performs every action of the partial plan whose predecessors are done on each update,
+// and waits for all of them before moving on to the actions that come after. The agent's current
+// actions are kept up to date with the actions that are left.
+//
+// Actions that need to move are moved to one at a time. A failing action is retried, skipped or
+// aborts the plan depending on its RetryPolicy, like in Do, while the other actions go on.
+func ParallelDo(fsm *FSM, agent Agent, debug func(string)) {
+	p := fsm.partial
+	if p == nil {
+		fsm.replan()
+		return
+	}
+
+	var remaining, ready []int
+	for i, action := range p.Actions {
+		if fsm.finished[i] {
+			continue
+		}
+		if action.IsDone() {
+			debug(fmt.Sprintf("ParallelDo - action %s is done", action))
+			fsm.finished[i] = true
+			if c := fsm.cooldowns(); c != nil {
+				c.Used(action)
+			}
+			continue
+		}
+		remaining = append(remaining, i)
+	}
+	for _, i := range remaining {
+		if fsm.predecessorsFinished(i) {
+			ready = append(ready, i)
+		}
+	}
+
+	if len(remaining) == 0 {
+		agent.SetCurrentActions(nil)
+		fsm.finish(agent)
+		return
+	}
+
+	// the action that has to move goes first, since MoveTo moves to the first current action
+	for _, i := range ready {
+		action := p.Actions[i]
+		if action.RequiresInRange() && !action.InRange(agent) {
+			fsm.setCurrentActions(agent, i, remaining)
+			debug(fmt.Sprintf("ParallelDo - scheduling moveTo %s", action))
+			fsm.Push(MoveTo)
+			return
+		}
+	}
+	fsm.setCurrentActions(agent, -1, remaining)
+
+	for _, i := range ready {
+		action := p.Actions[i]
+		retry := &fsm.retries[i]
+		// backing off before retrying a failed action
+		if retry.wait > 0 {
+			retry.wait--
+			continue
+		}
+		debug(fmt.Sprintf("ParallelDo - %s.Perform()", action))
+		if action.Perform(agent) {
+			retry.failures = 0
+			continue
+		}
+		policy := action.RetryPolicy()
+		if retry.failures < policy.Retries {
+			retry.failures++
+			retry.wait = policy.Backoff * retry.failures
+			debug(fmt.Sprintf("ParallelDo - %s failed, retry %d of %d", action, retry.failures, policy.Retries))
+			continue
+		}
+		if policy.OnFailure == SkipAction {
+			debug(fmt.Sprintf("ParallelDo - %s failed, skipping it", action))
+			if c := fsm.cooldowns(); c != nil {
+				c.Failed(action)
+			}
+			fsm.finished[i] = true
+			continue
+		}
+		fsm.abort(agent, action, ErrActionFailed)
+		return
+	}
+}
+
+func (fsm *FSM) predecessorsFinished(i int) bool {
+	for _, j := range fsm.partial.After[i] {
+		if !fsm.finished[j] {
+			return false
+		}
+	}
+	return true
+}
+
+// setCurrentActions sets the agent's current actions to the remaining actions, with first in front
+func (fsm *FSM) setCurrentActions(agent Agent, first int, remaining []int) {
+	actions := make([]Action, 0, len(remaining))
+	if first >= 0 {
+		actions = append(actions, fsm.partial.Actions[first])
+	}
+	for _, i := range remaining {
+		if i != first {
+			actions = append(actions, fsm.partial.Actions[i])
+		}
+	}
+	agent.SetCurrentActions(actions)
+}
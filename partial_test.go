@@ -0,0 +1,163 @@
+package goap
+
+import (
+	"fmt"
+	"testing"
+)
+
+func squadActions() (reload, callBackup, attack *flakyAction) {
+	reload = newFlakyAction("reload", 1, 0)
+	reload.AddEffect(State{"loaded", true})
+	callBackup = newFlakyAction("callBackup", 1, 0)
+	callBackup.AddEffect(State{"backupCalled", true})
+	attack = newFlakyAction("attack", 1, 0)
+	attack.AddPrecondition(State{"loaded", true}, State{"backupCalled", true})
+	attack.AddEffect(State{"enemyDead", true})
+	return reload, callBackup, attack
+}
+
+func TestNewPartialPlan(t *testing.T) {
+	reload, callBackup, attack := squadActions()
+	p := NewPartialPlan([]Action{reload, callBackup, attack})
+
+	if !p.Independent(0, 1) {
+		t.Error("expected reload and callBackup to be independent")
+	}
+	if p.Independent(0, 2) || p.Independent(1, 2) {
+		t.Error("expected attack to wait for reload and callBackup")
+	}
+	if len(p.After[2]) != 2 {
+		t.Errorf("expected attack to come after two actions, got %v", p.After[2])
+	}
+	if len(p.Links) != 2 {
+		t.Errorf("expected two causal links, got %v", p.Links)
+	}
+	for _, link := range p.Links {
+		if link.To != 2 {
+			t.Errorf("expected all links to go to attack, got %v", link)
+		}
+	}
+}
+
+func TestNewPartialPlan_chain(t *testing.T) {
+	p := NewPartialPlan([]Action{findFood(), eatAction()})
+
+	if p.Independent(0, 1) {
+		t.Error("expected eat to depend on getFood")
+	}
+	if len(p.After[1]) != 1 || p.After[1][0] != 0 {
+		t.Errorf("expected eat to come after getFood, got %v", p.After[1])
+	}
+}
+
+func TestParallelDo(t *testing.T) {
+	reload, callBackup, attack := squadActions()
+	agent := newRecordingAgent(reload, callBackup, attack)
+	agent.SetGoalState(StateList{"enemyDead": true})
+	agent.StateMachine = NewFSM(ParallelIdle)
+
+	// plan, then reload and call for backup at the same time
+	agent.Update()
+	agent.Update()
+	if *reload.performs != 1 || *callBackup.performs != 1 {
+		t.Errorf("expected reload and callBackup to be performed together, got %d and %d", *reload.performs, *callBackup.performs)
+	}
+	if *attack.performs != 0 {
+		t.Error("expected attack to wait for the others")
+	}
+
+	// attack, then finish
+	agent.Update()
+	agent.Update()
+	if *attack.performs != 1 {
+		t.Errorf("expected attack to be performed once, got %d", *attack.performs)
+	}
+	if agent.finished != 1 {
+		t.Errorf("expected the plan to finish, got %d", agent.finished)
+	}
+}
+
+func TestParallelDo_skip_cooldown(t *testing.T) {
+	reload, callBackup, attack := squadActions()
+	callBackup.failures = 1
+	callBackup.SetRetryPolicy(RetryPolicy{OnFailure: SkipAction})
+	agent := newRecordingAgent(reload, callBackup, attack)
+	agent.SetGoalState(StateList{"enemyDead": true})
+	agent.StateMachine = NewFSM(ParallelIdle)
+	agent.StateMachine.Planner = &Planner{Cooldowns: NewCooldowns(5)}
+
+	// plan, then reload while the call for backup fails and is skipped
+	agent.Update()
+	agent.Update()
+	if !agent.StateMachine.Planner.Cooldowns.Active(callBackup, nil) {
+		t.Error("expected the skipped action to be cooling down")
+	}
+}
+
+func TestParallelDo_retry(t *testing.T) {
+	reload, callBackup, attack := squadActions()
+	reload.failures = 1
+	reload.SetRetryPolicy(RetryPolicy{Retries: 1})
+	agent := newRecordingAgent(reload, callBackup, attack)
+	agent.SetGoalState(StateList{"enemyDead": true})
+	agent.StateMachine = NewFSM(ParallelIdle)
+
+	// plan, reload fails while backup is called, reload again, attack, finish
+	for i := 0; i < 5; i++ {
+		agent.Update()
+	}
+	if len(agent.aborted) != 0 {
+		t.Fatalf("expected reload to be retried, got %v", agent.aborted)
+	}
+	if *reload.performs != 2 || *attack.performs != 1 {
+		t.Errorf("expected reload twice and attack once, got %d and %d", *reload.performs, *attack.performs)
+	}
+	if agent.finished != 1 {
+		t.Errorf("expected the plan to finish, got %d", agent.finished)
+	}
+}
+
+func TestParallelDo_macro(t *testing.T) {
+	reload, callBackup, attack := squadActions()
+	prepare, err := NewMacroAction("prepare", reload, callBackup)
+	if err != nil {
+		t.Fatal(err)
+	}
+	agent := newRecordingAgent(prepare, attack)
+	agent.SetGoalState(StateList{"enemyDead": true})
+	agent.StateMachine = NewFSM(ParallelIdle)
+
+	agent.Update()
+	if actions := agent.CurrentActions(); len(actions) != 3 {
+		t.Fatalf("expected the macro to be replaced by its actions, got %v", actions)
+	}
+	// reload and call for backup, attack, finish
+	for i := 0; i < 3; i++ {
+		agent.Update()
+	}
+	if *reload.performs != 1 || *callBackup.performs != 1 || *attack.performs != 1 {
+		t.Errorf("expected every action to be performed once, got %d, %d and %d", *reload.performs, *callBackup.performs, *attack.performs)
+	}
+	if agent.finished != 1 {
+		t.Errorf("expected the plan to finish, got %d", agent.finished)
+	}
+}
+
+func TestNewPartialPlan_transitive(t *testing.T) {
+	// each action needs the one before it, so only the direct predecessor is kept
+	var plan []Action
+	for i := 0; i < 30; i++ {
+		action := newTestAction(fmt.Sprintf("step%d", i), 1, false)
+		if i > 0 {
+			action.AddPrecondition(State{fmt.Sprintf("done%d", i-1), true})
+		}
+		action.AddEffect(State{fmt.Sprintf("done%d", i), true}, State{"busy", true})
+		plan = append(plan, action)
+	}
+	p := NewPartialPlan(plan)
+	for j := 1; j < len(plan); j++ {
+		if len(p.After[j]) != 1 || p.After[j][0] != j-1 {
+			t.Fatalf("expected step%d to only come after step%d, got %v", j, j-1, p.After[j])
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package pddl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stojg/goap"
+)
+
+const gripperDomain = `; a small version of the gripper domain
+(define (domain gripper)
+  (:requirements :strips :typing :negative-preconditions :action-costs)
+  (:types room ball - object)
+  (:constants hand - object)
+  (:predicates (at ?b - ball ?r - room) (at-robby ?r - room) (carry ?b - ball) (free ?h))
+  (:functions (total-cost) - number)
+  (:action move
+    :parameters (?from ?to - room)
+    :precondition (and (at-robby ?from) (not (at-robby ?to)))
+    :effect (and (at-robby ?to) (not (at-robby ?from)) (increase (total-cost) 3)))
+  (:action pick
+    :parameters (?b - ball ?r - room)
+    :precondition (and (at ?b ?r) (at-robby ?r) (free hand))
+    :effect (and (carry ?b) (not (at ?b ?r)) (not (free hand))))
+  (:action drop
+    :parameters (?b - ball ?r - room)
+    :precondition (and (carry ?b) (at-robby ?r))
+    :effect (and (at ?b ?r) (free hand) (not (carry ?b)))))
+`
+
+const gripperProblem = `(define (problem move-ball)
+  (:domain gripper)
+  (:objects rooma roomb - room ball1 - ball)
+  (:init (= (total-cost) 0) (at-robby rooma) (at ball1 rooma) (free hand))
+  (:goal (and (at ball1 roomb)))
+  (:metric minimize (total-cost)))
+`
+
+type testAction struct {
+	goap.DefaultAction
+}
+
+func (a *testAction) Perform(agent goap.Agent) bool {
+	return true
+}
+
+func newTestAction(name string, cost float64) *testAction {
+	return &testAction{DefaultAction: goap.NewAction(name, cost)}
+}
+
+func readGripper(t *testing.T) ([]goap.Action, goap.StateList, goap.StateList) {
+	domain, err := ReadDomain(strings.NewReader(gripperDomain))
+	if err != nil {
+		t.Fatal(err)
+	}
+	problem, err := ReadProblem(strings.NewReader(gripperProblem))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if domain.Name != "gripper" || problem.Domain != "gripper" {
+		t.Errorf("expected the gripper domain, got %s and %s", domain.Name, problem.Domain)
+	}
+	actions, state := domain.Ground(problem)
+	return actions, state, problem.Goal
+}
+
+func TestReadDomain(t *testing.T) {
+	actions, state, goal := readGripper(t)
+
+	// move is grounded for every pair of rooms, pick and drop for the ball in each room
+	if len(actions) != 8 {
+		t.Errorf("expected 8 ground actions, got %v", actions)
+	}
+	if state["carry(ball1)"] || !state["at(ball1,rooma)"] {
+		t.Errorf("expected facts missing from init to be false, got %v", state)
+	}
+
+	plan := goap.Plan(&goap.DefaultAgent{}, actions, state, goal)
+	var names []string
+	for _, action := range plan {
+		names = append(names, action.String())
+	}
+	expected := "pick(ball1,rooma) move(rooma,roomb) drop(ball1,roomb)"
+	if strings.Join(names, " ") != expected {
+		t.Errorf("expected %s, got %v", expected, names)
+	}
+	if plan[1].Cost() != 3 {
+		t.Errorf("expected move to cost 3, got %g", plan[1].Cost())
+	}
+}
+
+func TestReadDomain_unsupported(t *testing.T) {
+	domain := `(define (domain d)
+  (:action a :parameters () :precondition (or (p) (q)) :effect (p)))`
+	if _, err := ReadDomain(strings.NewReader(domain)); err == nil || !strings.Contains(err.Error(), "unsupported or") {
+		t.Errorf("expected disjunctions to be unsupported, got %v", err)
+	}
+	if _, err := ReadDomain(strings.NewReader("(define (domain d)")); err == nil {
+		t.Error("expected an error for a missing )")
+	}
+}
+
+func TestWriteDomain(t *testing.T) {
+	getFood := newTestAction("getFood", 8)
+	getFood.AddPrecondition(goap.State{Name: "hasFood", Value: false}, goap.State{Name: "at(food,kitchen)", Value: true})
+	getFood.AddEffect(goap.State{Name: "hasFood", Value: true})
+	eat := newTestAction("eat", 4)
+	eat.AddPrecondition(goap.State{Name: "hasFood", Value: true}, goap.State{Name: "hungry", Value: true})
+	eat.AddEffect(goap.State{Name: "hungry", Value: false}, goap.State{Name: "hasFood", Value: false})
+	actions := []goap.Action{getFood, eat}
+
+	state := goap.StateList{"hungry": true, "hasFood": false, "at(food,kitchen)": true}
+	goal := goap.StateList{"hungry": false}
+
+	var domainFile, problemFile bytes.Buffer
+	if err := WriteDomain(&domainFile, "food", actions); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteProblem(&problemFile, "lunch", "food", actions, state, goal); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		"(:constants food kitchen)",
+		"(:predicates (at ?a0 ?a1) (hasFood) (hungry))",
+		":precondition (and (at food kitchen) (not (hasFood)))",
+		"(increase (total-cost) 8)",
+	} {
+		if !strings.Contains(domainFile.String(), expected) {
+			t.Errorf("expected the domain to contain %s, got\n%s", expected, domainFile.String())
+		}
+	}
+	if !strings.Contains(problemFile.String(), "(:init (= (total-cost) 0) (at food kitchen) (hungry))") {
+		t.Errorf("expected only the true facts in init, got\n%s", problemFile.String())
+	}
+
+	// reading them back gives the same plan
+	domain, err := ReadDomain(&domainFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	problem, err := ReadProblem(&problemFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	read, readState := domain.Ground(problem)
+	plan := goap.Plan(&goap.DefaultAgent{}, read, readState, problem.Goal)
+	if len(plan) != 2 || plan[0].String() != "getFood" || plan[0].Cost() != 8 || plan[1].String() != "eat" {
+		t.Errorf("expected getFood and eat, got %v", plan)
+	}
+}
+
+func TestWriteDomain_arity(t *testing.T) {
+	move := newTestAction("move", 1)
+	move.AddPrecondition(goap.State{Name: "at(kitchen)", Value: true})
+	move.AddEffect(goap.State{Name: "at(agent,hall)", Value: true})
+	if err := WriteDomain(&bytes.Buffer{}, "d", []goap.Action{move}); err == nil {
+		t.Error("expected an error for at with one and two arguments")
+	}
+}
+
+func TestWriteProblem_missing_false_state(t *testing.T) {
+	getFood := newTestAction("getFood", 1)
+	getFood.AddPrecondition(goap.State{Name: "hasFood", Value: false})
+	getFood.AddEffect(goap.State{Name: "hasFood", Value: true})
+	actions := []goap.Action{getFood}
+	goal := goap.StateList{"hasFood": true}
+
+	// GOAP can't plan getFood as hasFood isn't known to be false
+	err := WriteProblem(&bytes.Buffer{}, "p", "d", actions, goap.StateList{}, goal)
+	if err == nil || !strings.Contains(err.Error(), "hasFood") {
+		t.Errorf("expected an error for the missing hasFood, got %v", err)
+	}
+	if err := WriteProblem(&bytes.Buffer{}, "p", "d", actions, goap.StateList{"hasFood": false}, goal); err != nil {
+		t.Errorf("expected no error when hasFood is false, got %v", err)
+	}
+}
+
+func TestWriteDomain_name_clash(t *testing.T) {
+	actions := []goap.Action{newTestAction("go north", 1), newTestAction("go-north", 1)}
+	if err := WriteDomain(&bytes.Buffer{}, "d", actions); err == nil {
+		t.Error("expected an error for two actions called go-north")
+	}
+}
+
+func TestReadPlan(t *testing.T) {
+	actions, _, _ := readGripper(t)
+	plan, err := ReadPlan(strings.NewReader(`; found by some planner
+0: (PICK ball1 rooma) [1]
+1: (move rooma roomb) [3]
+(drop-ball1-roomb)
+`), actions)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan) != 3 || plan[0].String() != "pick(ball1,rooma)" || plan[2].String() != "drop(ball1,roomb)" {
+		t.Errorf("expected pick, move and drop, got %v", plan)
+	}
+	if goap.Definition(plan[1]) == plan[1] {
+		t.Error("expected the plan to be made of instances")
+	}
+
+	if _, err := ReadPlan(strings.NewReader("(fly rooma roomb)"), actions); err == nil {
+		t.Error("expected an error for an unknown action")
+	}
+}
@@ -0,0 +1,46 @@
+package pddl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/stojg/goap"
+)
+
+// ReadPlan reads a plan found by a PDDL planner for a domain written by WriteDomain or read by
+// ReadDomain, and returns it as instances of the actions. Each step is a line like (pickup apple),
+// optionally with a step number in front like "0: (pickup apple) [1]" and a cost after it. Lines
+// that are empty or start with a semicolon are skipped.
+func ReadPlan(r io.Reader, actions []goap.Action) ([]goap.Action, error) {
+	byName := make(map[string]goap.Action, len(actions))
+	for _, action := range actions {
+		byName[strings.ToLower(Name(action.String()))] = action
+	}
+
+	var plan []goap.Action
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, ";") {
+			continue
+		}
+		open, end := strings.IndexByte(text, '('), strings.IndexByte(text, ')')
+		if open < 0 || end < open {
+			return nil, fmt.Errorf("pddl: plan line %d: expected a step like (action args), got %q", line, text)
+		}
+		step := strings.Fields(text[open+1 : end])
+		if len(step) == 0 {
+			return nil, fmt.Errorf("pddl: plan line %d: empty step", line)
+		}
+		// actions with parameters are grounded to names like pickup(apple)
+		name := Name(goap.Predicate(step[0], step[1:]...))
+		action, found := byName[strings.ToLower(name)]
+		if !found {
+			return nil, fmt.Errorf("pddl: plan line %d: unknown action %s", line, text[open:end+1])
+		}
+		plan = append(plan, goap.NewInstance(action))
+	}
+	return plan, scanner.Err()
+}
@@ -0,0 +1,286 @@
+package pddl
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/stojg/goap"
+)
+
+// Domain is a PDDL domain, its actions are goap schemas that are grounded with the objects of a
+// Problem.
+type Domain struct {
+	Name    string
+	Schemas []*goap.Schema
+	// The objects that are part of the domain.
+	Constants goap.Objects
+
+	// the parent of each type
+	parents map[string]string
+}
+
+// Problem is a PDDL problem. Init only holds the facts that are true, see Domain.Ground.
+type Problem struct {
+	Name    string
+	Domain  string
+	Objects goap.Objects
+	Init    goap.StateList
+	Goal    goap.StateList
+}
+
+// ReadDomain reads a PDDL domain file. Actions cost 1 unless they increase total-cost.
+func ReadDomain(r io.Reader) (*Domain, error) {
+	e, err := parse(r)
+	if err != nil {
+		return nil, err
+	}
+	if e.head() != "define" {
+		return nil, fmt.Errorf("pddl: expected define, got %s", e)
+	}
+	d := &Domain{
+		Constants: make(goap.Objects),
+		parents:   make(map[string]string),
+	}
+	for _, section := range e.list[1:] {
+		switch section.head() {
+		case "domain":
+			if len(section.list) != 2 {
+				return nil, fmt.Errorf("pddl: bad domain name %s", section)
+			}
+			d.Name = section.list[1].atom
+		case ":types":
+			types, err := parseTyped(section.list[1:])
+			if err != nil {
+				return nil, err
+			}
+			for _, t := range types {
+				d.parents[t.name] = t.typ
+			}
+		case ":constants":
+			constants, err := parseTyped(section.list[1:])
+			if err != nil {
+				return nil, err
+			}
+			for _, c := range constants {
+				d.Constants[c.typ] = append(d.Constants[c.typ], c.name)
+			}
+		case ":action":
+			schema, err := parseAction(section)
+			if err != nil {
+				return nil, err
+			}
+			d.Schemas = append(d.Schemas, schema)
+		case ":requirements", ":predicates", ":functions":
+			// the requirements aren't checked and the predicates are known from the actions
+		default:
+			return nil, fmt.Errorf("pddl: domain %s: unsupported section %s", d.Name, section.head())
+		}
+	}
+	return d, nil
+}
+
+func parseAction(e *expr) (*goap.Schema, error) {
+	if len(e.list) < 2 || e.list[1].isList {
+		return nil, fmt.Errorf("pddl: action without a name")
+	}
+	s := &goap.Schema{Name: e.list[1].atom, Cost: 1}
+	for i := 2; i+1 < len(e.list); i += 2 {
+		value := e.list[i+1]
+		var err error
+		switch e.list[i].keyword() {
+		case ":parameters":
+			var params []typedName
+			params, err = parseTyped(value.list)
+			for _, p := range params {
+				s.Params = append(s.Params, goap.Param{Name: p.name, Type: p.typ})
+			}
+		case ":precondition":
+			s.Preconditions, err = parseLiterals(value, nil)
+		case ":effect":
+			s.Effects, err = parseLiterals(value, &s.Cost)
+		default:
+			err = fmt.Errorf("pddl: unsupported %s", e.list[i].atom)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("pddl: action %s: %w", s.Name, err)
+		}
+	}
+	return s, nil
+}
+
+// parseLiterals reads a conjunction of literals like (and (at ?x ?y) (not (holding ?x))). If cost
+// isn't nil, it's set to the amount of an (increase (total-cost) n).
+func parseLiterals(e *expr, cost *float64) ([]goap.State, error) {
+	if !e.isList {
+		return nil, fmt.Errorf("expected a list, got %s", e)
+	}
+	switch e.head() {
+	case "":
+		if len(e.list) == 0 {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unexpected %s", e)
+	case "and":
+		var result []goap.State
+		for _, item := range e.list[1:] {
+			states, err := parseLiterals(item, cost)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, states...)
+		}
+		return result, nil
+	case "not":
+		if len(e.list) != 2 || !e.list[1].isList || e.list[1].head() == "" {
+			return nil, fmt.Errorf("bad negation %s", e)
+		}
+		name, err := parseAtom(e.list[1])
+		if err != nil {
+			return nil, err
+		}
+		return []goap.State{{Name: name, Value: false}}, nil
+	case "increase":
+		if cost == nil || len(e.list) != 3 || e.list[1].head() != "total-cost" || e.list[2].isList {
+			return nil, fmt.Errorf("unsupported %s", e)
+		}
+		c, err := strconv.ParseFloat(e.list[2].atom, 64)
+		if err != nil {
+			return nil, fmt.Errorf("bad cost in %s", e)
+		}
+		*cost = c
+		return nil, nil
+	case "or", "imply", "exists", "forall", "when", "=":
+		return nil, fmt.Errorf("unsupported %s, only conjunctions of literals are", e.head())
+	}
+	name, err := parseAtom(e)
+	if err != nil {
+		return nil, err
+	}
+	return []goap.State{{Name: name, Value: true}}, nil
+}
+
+// parseAtom turns (at ?x ?y) into the state name at(?x,?y)
+func parseAtom(e *expr) (string, error) {
+	args := make([]string, len(e.list)-1)
+	for i, arg := range e.list[1:] {
+		if arg.isList {
+			return "", fmt.Errorf("bad atom %s", e)
+		}
+		args[i] = arg.atom
+	}
+	return goap.Predicate(e.list[0].atom, args...), nil
+}
+
+// ReadProblem reads a PDDL problem file.
+func ReadProblem(r io.Reader) (*Problem, error) {
+	e, err := parse(r)
+	if err != nil {
+		return nil, err
+	}
+	if e.head() != "define" {
+		return nil, fmt.Errorf("pddl: expected define, got %s", e)
+	}
+	p := &Problem{
+		Objects: make(goap.Objects),
+		Init:    make(goap.StateList),
+		Goal:    make(goap.StateList),
+	}
+	for _, section := range e.list[1:] {
+		switch section.head() {
+		case "problem":
+			if len(section.list) != 2 {
+				return nil, fmt.Errorf("pddl: bad problem name %s", section)
+			}
+			p.Name = section.list[1].atom
+		case ":domain":
+			if len(section.list) != 2 {
+				return nil, fmt.Errorf("pddl: bad domain name %s", section)
+			}
+			p.Domain = section.list[1].atom
+		case ":objects":
+			objects, err := parseTyped(section.list[1:])
+			if err != nil {
+				return nil, err
+			}
+			for _, o := range objects {
+				p.Objects[o.typ] = append(p.Objects[o.typ], o.name)
+			}
+		case ":init":
+			for _, fact := range section.list[1:] {
+				if fact.head() == "=" {
+					// the starting total-cost
+					continue
+				}
+				if !fact.isList || fact.head() == "" {
+					return nil, fmt.Errorf("pddl: problem %s: bad fact %s", p.Name, fact)
+				}
+				name, err := parseAtom(fact)
+				if err != nil {
+					return nil, fmt.Errorf("pddl: problem %s: %w", p.Name, err)
+				}
+				p.Init[name] = true
+			}
+		case ":goal":
+			if len(section.list) != 2 {
+				return nil, fmt.Errorf("pddl: problem %s: bad goal %s", p.Name, section)
+			}
+			goal, err := parseLiterals(section.list[1], nil)
+			if err != nil {
+				return nil, fmt.Errorf("pddl: problem %s: goal: %w", p.Name, err)
+			}
+			for _, s := range goal {
+				p.Goal[s.Name] = s.Value
+			}
+		case ":metric", ":requirements":
+			// plans are always the cheapest
+		default:
+			return nil, fmt.Errorf("pddl: problem %s: unsupported section %s", p.Name, section.head())
+		}
+	}
+	return p, nil
+}
+
+// Ground creates the actions of the domain for the objects of the problem, and the world state to
+// plan from. PDDL assumes that facts that aren't in the initial state are false, so the world state
+// has every fact that the actions or the goal use, with the ones missing from Init set to false.
+func (d *Domain) Ground(p *Problem) ([]goap.Action, goap.StateList) {
+	objects := make(goap.Objects)
+	add := func(from goap.Objects) {
+		for typ, names := range from {
+			// objects are also objects of the parent types
+			for t, seen := typ, make(map[string]bool); !seen[t]; t = d.parent(t) {
+				seen[t] = true
+				objects[t] = append(objects[t], names...)
+			}
+		}
+	}
+	add(d.Constants)
+	add(p.Objects)
+
+	actions := goap.GroundAll(d.Schemas, objects, p.Init)
+
+	state := make(goap.StateList)
+	for _, action := range actions {
+		for name := range action.Preconditions() {
+			state[name] = false
+		}
+		for name := range action.Effects() {
+			state[name] = false
+		}
+	}
+	for name := range p.Goal {
+		state[name] = false
+	}
+	for name, value := range p.Init {
+		state[name] = value
+	}
+	return actions, state
+}
+
+func (d *Domain) parent(typ string) string {
+	if parent, found := d.parents[typ]; found {
+		return parent
+	}
+	return "object"
+}
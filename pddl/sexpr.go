@@ -0,0 +1,135 @@
+// Package pddl converts between goap actions and PDDL domain, problem and plan files. It covers the
+// STRIPS subset of PDDL with types, constants, negative preconditions and action costs.
+package pddl
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// expr is an s-expression, either an atom or a list
+type expr struct {
+	atom   string
+	list   []*expr
+	isList bool
+}
+
+// keyword returns the atom in lower case, PDDL keywords and names aren't case sensitive
+func (e *expr) keyword() string {
+	return strings.ToLower(e.atom)
+}
+
+// head returns the keyword the list starts with
+func (e *expr) head() string {
+	if !e.isList || len(e.list) == 0 || e.list[0].isList {
+		return ""
+	}
+	return e.list[0].keyword()
+}
+
+func (e *expr) String() string {
+	if !e.isList {
+		return e.atom
+	}
+	parts := make([]string, len(e.list))
+	for i, item := range e.list {
+		parts[i] = item.String()
+	}
+	return "(" + strings.Join(parts, " ") + ")"
+}
+
+// parse reads one s-expression, comments start with a semicolon
+func parse(r io.Reader) (*expr, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	tokens := tokenize(string(data))
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("pddl: empty input")
+	}
+	e, rest, err := parseTokens(tokens)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("pddl: unexpected %q after the end", rest[0])
+	}
+	return e, nil
+}
+
+func tokenize(s string) []string {
+	var tokens []string
+	for _, line := range strings.Split(s, "\n") {
+		if i := strings.IndexByte(line, ';'); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.NewReplacer("(", " ( ", ")", " ) ").Replace(line)
+		tokens = append(tokens, strings.Fields(line)...)
+	}
+	return tokens
+}
+
+func parseTokens(tokens []string) (*expr, []string, error) {
+	if len(tokens) == 0 {
+		return nil, nil, fmt.Errorf("pddl: missing )")
+	}
+	switch tokens[0] {
+	case ")":
+		return nil, nil, fmt.Errorf("pddl: unexpected )")
+	case "(":
+		e := &expr{isList: true}
+		tokens = tokens[1:]
+		for {
+			if len(tokens) == 0 {
+				return nil, nil, fmt.Errorf("pddl: missing )")
+			}
+			if tokens[0] == ")" {
+				return e, tokens[1:], nil
+			}
+			item, rest, err := parseTokens(tokens)
+			if err != nil {
+				return nil, nil, err
+			}
+			e.list = append(e.list, item)
+			tokens = rest
+		}
+	default:
+		return &expr{atom: tokens[0]}, tokens[1:], nil
+	}
+}
+
+// typedName is a name in a typed list like "?x ?y - block"
+type typedName struct {
+	name string
+	typ  string
+}
+
+// parseTyped reads a typed list, names without a type are objects
+func parseTyped(items []*expr) ([]typedName, error) {
+	var result []typedName
+	var pending []string
+	for i := 0; i < len(items); i++ {
+		item := items[i]
+		if item.isList {
+			return nil, fmt.Errorf("pddl: unexpected %s in typed list", item)
+		}
+		if item.atom != "-" {
+			pending = append(pending, item.atom)
+			continue
+		}
+		if i+1 == len(items) || items[i+1].isList {
+			return nil, fmt.Errorf("pddl: missing type after -")
+		}
+		i++
+		for _, name := range pending {
+			result = append(result, typedName{name, items[i].atom})
+		}
+		pending = nil
+	}
+	for _, name := range pending {
+		result = append(result, typedName{name, "object"})
+	}
+	return result, nil
+}
@@ -0,0 +1,224 @@
+package pddl
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/stojg/goap"
+)
+
+// WriteDomain writes the actions as a PDDL domain. Every action becomes an action without
+// parameters, and state names that are predicates, like at(apple,kitchen), become atoms like
+// (at apple kitchen) with the arguments as constants. Names are made safe for PDDL by replacing the
+// characters it doesn't allow with dashes. If an action costs anything else than 1, the domain uses
+// action costs. It returns an error if two actions or predicates end up with the same name, or a
+// predicate is used with different numbers of arguments.
+func WriteDomain(w io.Writer, name string, actions []goap.Action) error {
+	if err := checkNames(actions); err != nil {
+		return err
+	}
+	predicates := make(map[string]int)
+	constants := make(map[string]bool)
+	collect := func(state goap.StateList) error {
+		for s := range state {
+			p, args := goap.ParsePredicate(s)
+			if n, found := predicates[Name(p)]; found && n != len(args) {
+				return fmt.Errorf("pddl: predicate %s is used with %d and %d arguments", Name(p), n, len(args))
+			}
+			predicates[Name(p)] = len(args)
+			for _, arg := range args {
+				constants[Name(arg)] = true
+			}
+		}
+		return nil
+	}
+	for _, action := range actions {
+		if err := collect(action.Preconditions()); err != nil {
+			return err
+		}
+		if err := collect(action.Effects()); err != nil {
+			return err
+		}
+	}
+	costs := useCosts(actions)
+
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "(define (domain %s)\n", Name(name))
+	if costs {
+		fmt.Fprintln(b, "  (:requirements :strips :negative-preconditions :action-costs)")
+	} else {
+		fmt.Fprintln(b, "  (:requirements :strips :negative-preconditions)")
+	}
+	if len(constants) > 0 {
+		fmt.Fprintf(b, "  (:constants %s)\n", strings.Join(sortedKeys(constants), " "))
+	}
+	fmt.Fprint(b, "  (:predicates")
+	for _, p := range sortedKeys(predicates) {
+		fmt.Fprintf(b, " (%s", p)
+		for i := 0; i < predicates[p]; i++ {
+			fmt.Fprintf(b, " ?a%d", i)
+		}
+		fmt.Fprint(b, ")")
+	}
+	fmt.Fprintln(b, ")")
+	if costs {
+		fmt.Fprintln(b, "  (:functions (total-cost) - number)")
+	}
+	for _, action := range actions {
+		fmt.Fprintf(b, "  (:action %s\n", Name(action.String()))
+		fmt.Fprintln(b, "    :parameters ()")
+		fmt.Fprintf(b, "    :precondition (and%s)\n", literals(action.Preconditions()))
+		effects := literals(action.Effects())
+		if costs {
+			effects += fmt.Sprintf(" (increase (total-cost) %g)", action.Cost())
+		}
+		fmt.Fprintf(b, "    :effect (and%s))\n", effects)
+	}
+	fmt.Fprintln(b, ")")
+	return b.Flush()
+}
+
+// WriteProblem writes a PDDL problem for planning with the actions of a domain written by
+// WriteDomain. Only the facts that are true in the world state are written, as PDDL assumes that
+// all others are false.
+//
+// GOAP doesn't assume that: a state that is missing from the world state doesn't meet a
+// precondition or goal that it's false. A PDDL planner could then find plans that GOAP never
+// would, so WriteProblem returns an error if a state that an action or the goal needs to be false
+// is missing from the world state. Adding it to the world state as false gives the same problem in
+// both.
+func WriteProblem(w io.Writer, name, domain string, actions []goap.Action, state, goal goap.StateList) error {
+	needsFalse := []goap.StateList{goal}
+	for _, action := range actions {
+		needsFalse = append(needsFalse, action.Preconditions())
+	}
+	for _, needed := range needsFalse {
+		for _, s := range sortedKeys(needed) {
+			if _, found := state[s]; !found && !needed[s] {
+				return fmt.Errorf("pddl: %s must be false but is missing from the world state, add it as false", s)
+			}
+		}
+	}
+
+	b := bufio.NewWriter(w)
+	fmt.Fprintf(b, "(define (problem %s)\n", Name(name))
+	fmt.Fprintf(b, "  (:domain %s)\n", Name(domain))
+	fmt.Fprint(b, "  (:init")
+	if useCosts(actions) {
+		fmt.Fprint(b, " (= (total-cost) 0)")
+	}
+	var facts []string
+	for s, value := range state {
+		if value {
+			facts = append(facts, atom(s))
+		}
+	}
+	sort.Strings(facts)
+	for _, fact := range facts {
+		fmt.Fprintf(b, " %s", fact)
+	}
+	fmt.Fprintln(b, ")")
+	fmt.Fprintf(b, "  (:goal (and%s))\n", literals(goal))
+	if useCosts(actions) {
+		fmt.Fprintln(b, "  (:metric minimize (total-cost))")
+	}
+	fmt.Fprintln(b, ")")
+	return b.Flush()
+}
+
+// Name makes a name safe for PDDL. Characters other than letters, digits, dashes and underscores
+// are replaced with dashes, and names that don't start with a letter get an x in front.
+func Name(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range s {
+		if r < 128 && (r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '_') {
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		if !dash {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	name := strings.Trim(b.String(), "-")
+	if name == "" || !(name[0] >= 'a' && name[0] <= 'z' || name[0] >= 'A' && name[0] <= 'Z') {
+		name = "x" + name
+	}
+	return name
+}
+
+// checkNames returns an error if two actions or two state names would be written with the same name
+func checkNames(actions []goap.Action) error {
+	seen := make(map[string]string)
+	check := func(kind, original string) error {
+		key := kind + " " + strings.ToLower(Name(original))
+		if other, found := seen[key]; found && other != original {
+			return fmt.Errorf("pddl: %s and %s have the same %s name %s", other, original, kind, Name(original))
+		}
+		seen[key] = original
+		return nil
+	}
+	for _, action := range actions {
+		if err := check("action", action.String()); err != nil {
+			return err
+		}
+		for _, state := range []goap.StateList{action.Preconditions(), action.Effects()} {
+			for s := range state {
+				if err := check("predicate", atom(s)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// atom turns a state name like at(apple,kitchen) into (at apple kitchen)
+func atom(s string) string {
+	name, args := goap.ParsePredicate(s)
+	parts := []string{Name(name)}
+	for _, arg := range args {
+		parts = append(parts, Name(arg))
+	}
+	return "(" + strings.Join(parts, " ") + ")"
+}
+
+// literals writes the state as a list of literals, in order, each with a space in front
+func literals(state goap.StateList) string {
+	var result []string
+	for s, value := range state {
+		if value {
+			result = append(result, atom(s))
+		} else {
+			result = append(result, "(not "+atom(s)+")")
+		}
+	}
+	sort.Strings(result)
+	if len(result) == 0 {
+		return ""
+	}
+	return " " + strings.Join(result, " ")
+}
+
+func useCosts(actions []goap.Action) bool {
+	for _, action := range actions {
+		if action.Cost() != 1 {
+			return true
+		}
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -2,33 +2,143 @@
 // Inspired by https://github.com/sploreg/goap/
 package goap
 
+import (
+	"math"
+	"math/bits"
+)
+
 // Plan what sequence of actions can fulfill the goal. Returns null if a plan could not be found, or
 // a list of the actions that must be performed, in order, to fulfill the goal.
-//
 func Plan(agent Agent, availableActions []Action, worldState StateList, goal StateList) []Action {
+	return (&Planner{}).Plan(agent, availableActions, worldState, goal)
+}
+
+// Objective is what the planner minimises when choosing between plans that reach the goal.
+type Objective int
+
+const (
+	// MinimiseCost picks the plan with the lowest total cost. This is the default.
+	MinimiseCost Objective = iota
+	// MinimiseMakespan picks the plan that finishes first, using the cost to break ties.
+	MinimiseMakespan
+)
+
+// SearchDirection is the direction the planner searches in.
+type SearchDirection int
+
+const (
+	// ForwardSearch searches from the world state towards the goal, trying the actions that can be
+	// used in each state. This is the default.
+	ForwardSearch SearchDirection = iota
+	// BackwardSearch searches from the goal towards the world state, only trying the actions that
+	// have an effect that is still needed. It can be much faster when there are many actions that
+	// have nothing to do with the goal. Planners with a TravelCost search forward, as the travel
+	// depends on where the agent is coming from.
+	BackwardSearch
+)
+
+// Planner holds the settings used when planning. The zero value plans the same way as Plan.
+type Planner struct {
+	// Cooldowns, if set, leaves out or penalises actions that recently failed or were used.
+	Cooldowns *Cooldowns
+	// Reservations, if set, leaves out targets that are reserved by other agents.
+	Reservations *Reservations
+	// Cache, if set, remembers found plans and reuses them for the same state, goal and actions.
+	Cache *PlanCache
+	// Stats, if set, counts the plans and the nodes expanded while searching for them.
+	Stats *PlanStats
+
+	// What to minimise when choosing between plans.
+	Objective Objective
+	// Which way to search.
+	Direction SearchDirection
+	// If larger than zero, plans that take longer than this are not considered.
+	Deadline float64
+	// Each action costs its Cost() plus its Duration() multiplied by TimeWeight.
+	TimeWeight float64
 
+	// TravelCost, if set, is added to the cost of every action that requires range, for moving to
+	// its target. The travel starts from the agent, or the target of the previous action in the plan
+	// that required range. Targets that cost math.Inf(1) to travel to are left out.
+	TravelCost TravelCost
+}
+
+// Plan what sequence of actions can fulfill the goal, see Plan.
+func (p *Planner) Plan(agent Agent, availableActions []Action, worldState StateList, goal StateList) []Action {
+	s := p.newSearch(agent)
+	plan := s.plan(availableActions, worldState, goal)
+	if p.Stats != nil {
+		p.Stats.add(1, int64(s.nodes))
+	}
+	return plan
+}
+
+func (p *Planner) newSearch(agent Agent) *search {
+	return &search{
+		Planner: p,
+		agent:   agent,
+		targets: make(map[Action][]Candidate),
+	}
+}
+
+func (s *search) plan(availableActions []Action, worldState StateList, goal StateList) []Action {
 	var result []Action
+	agent := s.agent
 
 	// check what actions can run
 	var usableActions []Action
-	for _, action := range availableActions {
-		// reset the actions so we can start fresh with them
+	for _, definition := range availableActions {
+		// plan with fresh instances so that the available actions aren't changed
+		action := NewInstance(definition)
 		action.Reset()
-		if action.CheckContextPrecondition(agent) {
-			usableActions = append(usableActions, action)
+		if !action.CheckContextPrecondition(agent) {
+			continue
 		}
+		targets := s.candidates(action)
+		if m, ok := action.(*MacroAction); ok {
+			targets = s.bindMacro(m, targets)
+		}
+		if len(targets) == 0 {
+			continue
+		}
+		s.targets[action] = targets
+		usableActions = append(usableActions, action)
 	}
 
 	if len(usableActions) == 0 {
 		return result
 	}
 
-	// we now have all actions that can run, stored in usableActions
+	// plans can be reused when nothing they depend on has changed
+	if s.Cache != nil && s.conflicts == nil && s.TravelCost == nil && s.trace == nil {
+		key, ok := s.cacheKey(worldState, goal, usableActions)
+		if ok {
+			if plan, found := s.cached(key, usableActions, goal); found {
+				return plan
+			}
+			plan := s.find(usableActions, worldState, goal)
+			s.cache(key, usableActions, goal, plan)
+			return plan
+		}
+	}
+	return s.find(usableActions, worldState, goal)
+}
+
+// find the best plan with the usable actions
+func (s *search) find(usableActions []Action, worldState StateList, goal StateList) []Action {
+	var result []Action
+
+	s.arena = newArena()
+	defer s.arena.release()
+	s.prepare(usableActions, worldState, goal)
+
 	// build up the tree and record the leaf nodes that provide a solution to the goal.
 	var leaves []*node
-	start := newNode(nil, 0, worldState, nil)
-	if !buildGraph(start, &leaves, usableActions, goal) {
-		return nil
+	backward := s.backward()
+	if backward {
+		s.buildBackward(s.goalNode(), &leaves)
+	} else {
+		s.buildGraph(s.start(worldState), &leaves)
 	}
 
 	// get the cheapest leaf
@@ -36,14 +146,30 @@ func Plan(agent Agent, availableActions []Action, worldState StateList, goal Sta
 	for _, leaf := range leaves {
 		if cheapest == nil {
 			cheapest = leaf
-		} else if leaf.runningCost < cheapest.runningCost {
+		} else if s.better(leaf, cheapest) {
 			cheapest = leaf
 		}
 	}
 
+	if s.trace != nil {
+		s.record(leaves, cheapest)
+	}
+	if cheapest == nil {
+		return nil
+	}
+
 	// go through the end node and work up to through it's parents
 	for n := cheapest; n != nil; n = n.parent {
 		if n.action != nil {
+			// bind the target that the planner chose
+			if _, ok := n.action.(TargetProvider); ok {
+				n.action.SetTarget(n.target)
+			}
+			if backward {
+				// the leaves of a backward search are the first action
+				result = append(result, n.action)
+				continue
+			}
 			// insert action in front
 			result = append([]Action{n.action}, result...)
 		}
@@ -51,33 +177,257 @@ func Plan(agent Agent, availableActions []Action, worldState StateList, goal Sta
 	return result
 }
 
+// search holds what is needed while searching for a plan in one call to Planner.Plan.
+type search struct {
+	*Planner
+	agent Agent
+	// the targets that each usable action can be planned with
+	targets map[Action][]Candidate
+	// if set, actions that it returns true for can't be used with the target after the node
+	conflicts func(parent *node, action Action, target interface{}) bool
+
+	// the state names, world state, goal and usable actions as bitsets, the index of the actions and
+	// where the nodes come from
+	index   *stateIndex
+	world   bitState
+	goal    condition
+	steps   []step
+	actions *actionIndex
+	arena   *arena
+	// how many nodes have been expanded
+	nodes int
+	// if set, the search is recorded here
+	trace *SearchTrace
+}
+
+// step is a usable action prepared for the search
+type step struct {
+	action        Action
+	preconditions condition
+	effects       condition
+	targets       []Candidate
+	// the action is already in the plan that is being built
+	used bool
+}
+
+// prepare the search to run on bitsets instead of StateLists
+func (s *search) prepare(usableActions []Action, worldState StateList, goal StateList) {
+	s.index = newStateIndex()
+	s.index.add(worldState)
+	s.index.add(goal)
+	for _, action := range usableActions {
+		s.index.add(action.Preconditions())
+		s.index.add(action.Effects())
+	}
+	s.world = s.index.state(worldState)
+	s.goal = s.index.condition(goal)
+	s.steps = make([]step, len(usableActions))
+	for i, action := range usableActions {
+		s.steps[i] = step{
+			action:        action,
+			preconditions: s.index.condition(action.Preconditions()),
+			effects:       s.index.condition(action.Effects()),
+			targets:       s.targetsOf(action),
+		}
+	}
+	s.actions = newActionIndex(s.index, s.steps)
+	if s.arena == nil {
+		s.arena = &arena{}
+	}
+}
+
+// start returns the root node of the search
+func (s *search) start(worldState StateList) *node {
+	start := s.arena.node()
+	start.state = s.arena.state(2 * s.index.words)
+	copy(start.state, s.index.state(worldState))
+	start.location = s.agent
+	start.applicable = s.arena.state(s.actions.words)
+	for i := range start.applicable {
+		start.applicable[i] = 0
+	}
+	for i := range s.steps {
+		if s.steps[i].preconditions.satisfiedBy(start.state) {
+			setBit(start.applicable, i)
+		}
+	}
+	return start
+}
+
+func (s *search) newNode(parent *node, runningCost float64, action Action) *node {
+	s.nodes++
+	n := s.arena.node()
+	n.parent = parent
+	n.runningCost = runningCost
+	n.state = s.arena.state(len(parent.state))
+	n.action = action
+	return n
+}
+
+// candidates returns the targets the action can be planned with. Actions that aren't a
+// TargetProvider keep the target they have, but they can't be planned if they have no target and
+// need to move to be in range.
+func (s *search) candidates(action Action) []Candidate {
+	var all []Candidate
+	if provider, ok := action.(TargetProvider); ok {
+		for _, c := range provider.Targets(s.agent) {
+			// an action that has to be moved to can't do without a target
+			if c.Target != nil || !action.RequiresInRange() {
+				all = append(all, c)
+			}
+		}
+	} else if !action.RequiresInRange() || action.Target() != nil || action.InRange(s.agent) {
+		all = []Candidate{{Target: action.Target()}}
+	}
+
+	// targets that are reserved by others, are cooling down, or are the wrong type for a typed
+	// action, are left out. Assignments are checked for the agent they are assigned to.
+	owner := s.agent
+	if a, ok := action.(*Assignment); ok {
+		owner = a.Agent
+	}
+	var result []Candidate
+	for _, c := range all {
+		if !acceptsTarget(action, c.Target) {
+			continue
+		}
+		if s.Reservations != nil && c.Target != nil && !s.Reservations.Available(c.Target, owner) {
+			continue
+		}
+		if s.Cooldowns != nil && s.Cooldowns.Penalty <= 0 && s.Cooldowns.Active(action, c.Target) {
+			continue
+		}
+		result = append(result, c)
+	}
+	return result
+}
+
+// bindMacro binds the actions of the macro that need a target to their cheapest candidate, and
+// returns the macro's candidates with the cost of those targets added. It returns nil if one of the
+// actions has no candidates.
+func (s *search) bindMacro(m *MacroAction, targets []Candidate) []Candidate {
+	extra := 0.0
+	for _, action := range m.actions {
+		if inner, ok := action.(*MacroAction); ok {
+			bound := s.bindMacro(inner, []Candidate{{}})
+			if bound == nil {
+				return nil
+			}
+			extra += bound[0].Cost
+			continue
+		}
+		if _, ok := action.(TargetProvider); !ok && (!action.RequiresInRange() || action.Target() != nil) {
+			continue
+		}
+		candidates := s.candidates(action)
+		if len(candidates) == 0 {
+			return nil
+		}
+		best := candidates[0]
+		for _, c := range candidates[1:] {
+			if c.Cost < best.Cost {
+				best = c
+			}
+		}
+		action.SetTarget(best.Target)
+		extra += best.Cost
+	}
+	result := make([]Candidate, len(targets))
+	for i, c := range targets {
+		c.Cost += extra
+		result[i] = c
+	}
+	return result
+}
+
+// targetsOf returns the candidates found for the action, or its current target
+func (s *search) targetsOf(action Action) []Candidate {
+	if targets, ok := s.targets[action]; ok {
+		return targets
+	}
+	return []Candidate{{Target: action.Target()}}
+}
+
 // buildGraph returns true if at least one solution was found. The possible paths are stored in the
 // leaves list. Each leaf has a 'runningCost' value where the lowest cost will be the best action
 // sequence.
-func buildGraph(parent *node, leaves *[]*node, usableActions []Action, goal StateList) bool {
+func (s *search) buildGraph(parent *node, leaves *[]*node) bool {
 	foundOne := false
 
-	// go through each action available at this node and see if we can use it here
-	for _, action := range usableActions {
+	// go through each action that can be used in the parent state
+	for w, word := range parent.applicable {
+		for ; word != 0; word &= word - 1 {
+			i := w*64 + bits.TrailingZeros64(word)
+			if s.steps[i].used {
+				continue
+			}
+			if s.expand(parent, leaves, i) {
+				foundOne = true
+			}
+		}
+	}
+	return foundOne
+}
+
+// expand the parent node with the i:th action, it returns true if at least one solution was found
+func (s *search) expand(parent *node, leaves *[]*node, i int) bool {
+	foundOne := false
+	step := &s.steps[i]
+	action := step.action
+
+	// don't bother with actions that would make us miss the deadline
+	elapsed := parent.elapsed + action.Duration()
+	if s.Deadline > 0 && elapsed > s.Deadline {
+		return false
+	}
 
-		// if the parent state has the conditions for this action's preconditions, we can use it here
-		if !inState(action.Preconditions(), parent.state) {
+	// branch out for every target the action can use
+	for _, candidate := range step.targets {
+		if s.conflicts != nil && s.conflicts(parent, action, candidate.Target) {
 			continue
 		}
+		cost := parent.runningCost + s.cost(action, candidate.Target) + candidate.Cost
+		location := parent.location
+		if action.RequiresInRange() {
+			if s.TravelCost != nil {
+				cost += s.TravelCost(s.agent, parent.location, candidate.Target)
+				// targets that can't be reached can't be used
+				if math.IsInf(cost, 1) {
+					continue
+				}
+			}
+			location = candidate.Target
+		}
+		// apply the action's effects to the parent state, and check the actions that they could
+		// have made usable or unusable
+		node := s.newNode(parent, cost, action)
+		step.effects.apply(node.state, parent.state)
+		node.applicable = s.arena.state(len(parent.applicable))
+		copy(node.applicable, parent.applicable)
+		for w, word := range s.actions.affects[i] {
+			for ; word != 0; word &= word - 1 {
+				j := w*64 + bits.TrailingZeros64(word)
+				if s.steps[j].preconditions.satisfiedBy(node.state) {
+					setBit(node.applicable, j)
+				} else {
+					clearBit(node.applicable, j)
+				}
+			}
+		}
+		node.elapsed = elapsed
+		node.target = candidate.Target
+		node.location = location
 
-		// apply the action's effects to the parent state
-		currentState := populateState(parent.state, action.Effects())
-		node := newNode(parent, parent.runningCost+action.Cost(), currentState, action)
-
-		if inState(goal, currentState) {
+		if s.goal.satisfiedBy(node.state) {
 			// we found a solution!
 			*leaves = append(*leaves, node)
 			foundOne = true
 		} else {
 
 			// not at a solution yet, so test all the remaining actions and branch out the tree
-			subset := actionSubset(usableActions, action)
-			found := buildGraph(node, leaves, subset, goal)
+			step.used = true
+			found := s.buildGraph(node, leaves)
+			step.used = false
 			if found {
 				foundOne = true
 			}
@@ -86,6 +436,23 @@ func buildGraph(parent *node, leaves *[]*node, usableActions []Action, goal Stat
 	return foundOne
 }
 
+// cost of the action including its weighted duration and any cooldown penalty
+func (p *Planner) cost(action Action, target interface{}) float64 {
+	cost := action.Cost() + p.TimeWeight*action.Duration()
+	if p.Cooldowns != nil && p.Cooldowns.Active(action, target) {
+		cost += p.Cooldowns.Penalty
+	}
+	return cost
+}
+
+// better returns true if the leaf a is a better solution than b for the planner's objective
+func (p *Planner) better(a, b *node) bool {
+	if p.Objective == MinimiseMakespan && a.elapsed != b.elapsed {
+		return a.elapsed < b.elapsed
+	}
+	return a.runningCost < b.runningCost
+}
+
 // Check that all items in 'test' are in 'state'. If just one does not match or is not there then
 // this returns false.
 func inState(test StateList, state StateList) bool {
@@ -120,29 +487,16 @@ func populateState(currentState StateList, stateChange StateList) StateList {
 	return state
 }
 
-func actionSubset(actions []Action, removeMe Action) []Action {
-	var subset []Action
-	for _, a := range actions {
-		if a != removeMe {
-			subset = append(subset, a)
-		}
-	}
-	return subset
-}
-
 // Node is used for building up the graph and holding the running costs of actions.
 type node struct {
 	parent      *node
 	runningCost float64
-	state       StateList
-	action      Action
-}
-
-func newNode(parent *node, runningCost float64, state StateList, action Action) *node {
-	return &node{
-		parent:      parent,
-		runningCost: runningCost,
-		state:       state,
-		action:      action,
-	}
+	elapsed     float64
+	state       bitState
+	// the actions whose preconditions are met in the state, as a bitset
+	applicable []uint64
+	action     Action
+	target     interface{}
+	// where the agent will be after this node, the agent itself if it hasn't moved yet
+	location interface{}
 }
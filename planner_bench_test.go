@@ -0,0 +1,72 @@
+package goap
+
+import (
+	"fmt"
+	"testing"
+)
+
+// benchDomains are synthetic domains of increasing size. The plan goes through depth layers, and
+// every layer can be crossed with width actions of different cost. The distractors are actions
+// whose preconditions are never met.
+var benchDomains = []struct {
+	actions, width, depth int
+}{
+	{5, 1, 5},
+	{10, 2, 4},
+	{20, 2, 6},
+	{50, 2, 10},
+}
+
+func benchDomain(actions, width, depth int) ([]Action, StateList, StateList) {
+	layer := func(l int) string {
+		return fmt.Sprintf("layer%d", l)
+	}
+
+	var result []Action
+	for l := 0; l < depth; l++ {
+		for w := 0; w < width; w++ {
+			a := newTestAction(fmt.Sprintf("cross%d_%d", l, w), float64(1+(l+w)%3), false)
+			a.AddPrecondition(State{layer(l), true})
+			a.AddEffect(State{layer(l), false}, State{layer(l + 1), true})
+			result = append(result, a)
+		}
+	}
+	for i := len(result); i < actions; i++ {
+		a := newTestAction(fmt.Sprintf("distract%d", i), 1, false)
+		a.AddPrecondition(State{fmt.Sprintf("never%d", i), true})
+		a.AddEffect(State{layer(i % (depth + 1)), true})
+		result = append(result, a)
+	}
+
+	worldState := StateList{layer(0): true}
+	goal := StateList{layer(depth): true}
+	return result, worldState, goal
+}
+
+func benchmarkPlan(b *testing.B, direction SearchDirection) {
+	for _, d := range benchDomains {
+		b.Run(fmt.Sprintf("actions=%d", d.actions), func(b *testing.B) {
+			actions, worldState, goal := benchDomain(d.actions, d.width, d.depth)
+			planner := &Planner{Direction: direction, Stats: &PlanStats{}}
+			if plan := planner.Plan(&DefaultAgent{}, actions, worldState, goal); len(plan) != d.depth {
+				b.Fatalf("expected a plan of %d actions, got %v", d.depth, plan)
+			}
+			planner.Stats.Reset()
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				planner.Plan(&DefaultAgent{}, actions, worldState, goal)
+			}
+			b.ReportMetric(float64(planner.Stats.Nodes())/float64(b.N), "nodes/op")
+		})
+	}
+}
+
+func BenchmarkPlan(b *testing.B) {
+	benchmarkPlan(b, ForwardSearch)
+}
+
+func BenchmarkPlan_backward(b *testing.B) {
+	benchmarkPlan(b, BackwardSearch)
+}
@@ -101,6 +101,80 @@ func TestPlan_failed(t *testing.T) {
 	}
 }
 
+// a quick but expensive and a slow but cheap way of getting food
+func timedFoodActions() []Action {
+	cook := newTestAction("cook", 2, false)
+	cook.AddEffect(HaveFood)
+	cook.SetDuration(30)
+
+	buy := newTestAction("buy", 8, false)
+	buy.AddEffect(HaveFood)
+	buy.SetDuration(5)
+
+	return []Action{cook, buy}
+}
+
+func TestPlanner_Objective(t *testing.T) {
+	goal := make(StateList)
+	goal.Add(HaveFood)
+
+	planner := &Planner{}
+	actionList := planner.Plan(&DefaultAgent{}, timedFoodActions(), make(StateList), goal)
+	if len(actionList) != 1 || actionList[0].String() != "cook" {
+		t.Errorf("expected the cheapest plan to be 'cook', got %v", actionList)
+	}
+
+	planner.Objective = MinimiseMakespan
+	actionList = planner.Plan(&DefaultAgent{}, timedFoodActions(), make(StateList), goal)
+	if len(actionList) != 1 || actionList[0].String() != "buy" {
+		t.Errorf("expected the fastest plan to be 'buy', got %v", actionList)
+	}
+}
+
+func TestPlanner_Deadline(t *testing.T) {
+	goal := make(StateList)
+	goal.Add(HaveFood)
+
+	planner := &Planner{Deadline: 10}
+	actionList := planner.Plan(&DefaultAgent{}, timedFoodActions(), make(StateList), goal)
+	if len(actionList) != 1 || actionList[0].String() != "buy" {
+		t.Errorf("expected 'buy' to be the only plan within the deadline, got %v", actionList)
+	}
+
+	planner.Deadline = 1
+	actionList = planner.Plan(&DefaultAgent{}, timedFoodActions(), make(StateList), goal)
+	if actionList != nil {
+		t.Errorf("expected no plan within the deadline, got %v", actionList)
+	}
+}
+
+func TestPlanner_TimeWeight(t *testing.T) {
+	goal := make(StateList)
+	goal.Add(HaveFood)
+
+	// cook costs 2+30*0.5 = 17 and buy costs 8+5*0.5 = 10.5
+	planner := &Planner{TimeWeight: 0.5}
+	actionList := planner.Plan(&DefaultAgent{}, timedFoodActions(), make(StateList), goal)
+	if len(actionList) != 1 || actionList[0].String() != "buy" {
+		t.Errorf("expected 'buy' to be cheapest when time is weighted in, got %v", actionList)
+	}
+}
+
+func TestSchedule(t *testing.T) {
+	actions := timedFoodActions()
+	steps := Schedule(100, actions)
+
+	if len(steps) != 2 {
+		t.Fatalf("expected 2 steps, got %d", len(steps))
+	}
+	if steps[0].Start != 100 || steps[0].End != 130 {
+		t.Errorf("expected first step to run 100-130, got %v-%v", steps[0].Start, steps[0].End)
+	}
+	if steps[1].Start != 130 || steps[1].End != 135 {
+		t.Errorf("expected second step to run 130-135, got %v-%v", steps[1].Start, steps[1].End)
+	}
+}
+
 func Test_buildGraph(t *testing.T) {
 	eatSlowly := newTestAction("eatSlowly", 8, false)
 	eatSlowly.AddEffect(Isnt(Hungry), Dont(HaveFood))
@@ -118,10 +192,10 @@ func Test_buildGraph(t *testing.T) {
 	goal := make(StateList)
 	goal.Isnt(Hungry)
 
-	start := newNode(nil, 0, currentState, nil)
-
 	var leaves []*node
-	found := buildGraph(start, &leaves, actions, goal)
+	s := &search{Planner: &Planner{}}
+	s.prepare(actions, currentState, goal)
+	found := s.buildGraph(s.start(currentState), &leaves)
 
 	if !found {
 		t.Error("expected to find a plan")
@@ -178,35 +252,6 @@ func Test_inState_dont_exists(t *testing.T) {
 	}
 }
 
-func Test_actionSubset(t *testing.T) {
-	eat := newTestAction("eat", 2, false)
-	drop := newTestAction("drop", 2, false)
-	hide := newTestAction("hide", 2, false)
-
-	actions := []Action{eat, drop, hide}
-
-	result := actionSubset(actions, drop)
-
-	if len(result) != 2 {
-		t.Errorf("expected one action to be removed, got %d", len(result))
-	}
-
-	if result[0] != eat {
-		t.Error("expected eat action")
-		return
-	}
-
-	if result[1] == drop {
-		t.Error("didnt expected drop action")
-		return
-	}
-
-	if result[1] != hide {
-		t.Error("did expected hide actiond")
-		return
-	}
-}
-
 func Test_populateState(t *testing.T) {
 
 	currentState := make(StateList)
@@ -245,21 +290,14 @@ func newTestAction(name string, cost float64, requiresInRange bool) *testAction
 	action := &testAction{
 		DefaultAction: NewAction(name, cost),
 	}
-	if !requiresInRange {
-		action.inRange = true
-	}
+	action.SetRequiresInRange(requiresInRange)
 	return action
 }
 
 type testAction struct {
 	DefaultAction
-	inRange bool
 }
 
 func (a *testAction) Perform(agent Agent) bool {
 	return true
 }
-
-func (a *testAction) InRange(agent Agent) bool {
-	return true
-}
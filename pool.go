@@ -0,0 +1,166 @@
+package goap
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Reasons for PlanPool.Submit to turn a request down.
+var (
+	ErrPoolBusy   = errors.New("plan pool is busy")
+	ErrPoolClosed = errors.New("plan pool is closed")
+)
+
+// NewPlanPool starts workers goroutines that plan in the background. Up to queueSize requests can
+// wait for a worker.
+func NewPlanPool(workers, queueSize int) *PlanPool {
+	p := &PlanPool{requests: make(chan *PlanRequest, queueSize)}
+	for i := 0; i < workers; i++ {
+		p.wg.Add(1)
+		go p.work()
+	}
+	return p
+}
+
+// PlanPool plans on a pool of worker goroutines, so that planning doesn't hold up the game loop.
+// When set on the FSM, Idle submits a request and polls for the result on the updates after.
+//
+// The state and goal are copied when a request is submitted, and the planner works on instances of
+// the actions, but the agent is shared. CheckContextPrecondition, Targets and InRange are called
+// on the worker, and must be safe to call while the game goes on.
+type PlanPool struct {
+	requests chan *PlanRequest
+	wg       sync.WaitGroup
+
+	// guards closing the requests while they are submitted
+	mu     sync.Mutex
+	closed bool
+}
+
+// PlanRequest is a plan that is being worked on by a PlanPool.
+type PlanRequest struct {
+	// The copies of the world state and goal that are planned with.
+	State StateList
+	Goal  StateList
+
+	planner *Planner
+	agent   Agent
+	actions []Action
+	done    chan struct{}
+	plan    []Action
+}
+
+// Submit queues a plan request without waiting. It returns ErrPoolBusy if the queue is full, and
+// ErrPoolClosed after Close.
+func (p *PlanPool) Submit(planner *Planner, agent Agent, actions []Action, worldState StateList, goal StateList) (*PlanRequest, error) {
+	r := &PlanRequest{
+		State:   copyState(worldState),
+		Goal:    copyState(goal),
+		planner: planner,
+		agent:   agent,
+		actions: append([]Action{}, actions...),
+		done:    make(chan struct{}),
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, ErrPoolClosed
+	}
+	select {
+	case p.requests <- r:
+		return r, nil
+	default:
+		return nil, ErrPoolBusy
+	}
+}
+
+// Close stops the workers once they have finished the requests that were submitted.
+func (p *PlanPool) Close() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.requests)
+	}
+	p.mu.Unlock()
+	p.wg.Wait()
+}
+
+func (p *PlanPool) work() {
+	defer p.wg.Done()
+	for r := range p.requests {
+		r.plan = r.planner.Plan(r.agent, r.actions, r.State, r.Goal)
+		close(r.done)
+	}
+}
+
+// Result returns the plan and true if the request is done, like Plan the plan is nil if no plan was
+// found. It returns false if the request is still being worked on.
+func (r *PlanRequest) Result() ([]Action, bool) {
+	select {
+	case <-r.done:
+		return r.plan, true
+	default:
+		return nil, false
+	}
+}
+
+// Wait for the request to be done and return the plan.
+func (r *PlanRequest) Wait() []Action {
+	<-r.done
+	return r.plan
+}
+
+// planAsync submits a plan request to the pool, or polls the one that has been submitted. It returns
+// true with the plan once it's ready. Plans for a state or goal that has changed since the request
+// was submitted are thrown away and planned again.
+func (fsm *FSM) planAsync(agent Agent, goal StateList, debug func(string)) ([]Action, bool) {
+	if fsm.pending == nil {
+		if !fsm.allowPlan(agent) {
+			debug("Idle - waiting to plan")
+			return nil, false
+		}
+		debug("Idle - submitting plan request")
+		request, err := fsm.Pool.Submit(fsm.planner(), agent, agent.AvailableActions(), agent.State(), goal)
+		if err != nil {
+			// try again on the next update
+			debug(fmt.Sprintf("Idle - %s", err))
+			return nil, false
+		}
+		fsm.pending = request
+		return nil, false
+	}
+
+	plan, ready := fsm.pending.Result()
+	if !ready {
+		debug("Idle - waiting for plan")
+		return nil, false
+	}
+	request := fsm.pending
+	fsm.pending = nil
+	if !equalStates(request.State, agent.State()) || !equalStates(request.Goal, goal) {
+		debug("Idle - state or goal changed, discarding plan")
+		return nil, false
+	}
+	return plan, true
+}
+
+func copyState(s StateList) StateList {
+	c := make(StateList, len(s))
+	for k, v := range s {
+		c[k] = v
+	}
+	return c
+}
+
+func equalStates(a, b StateList) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if w, found := b[k]; !found || w != v {
+			return false
+		}
+	}
+	return true
+}
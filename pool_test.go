@@ -0,0 +1,115 @@
+package goap
+
+import (
+	"testing"
+)
+
+func TestPlanPool(t *testing.T) {
+	pool := NewPlanPool(2, 2)
+	defer pool.Close()
+
+	currentState := make(StateList)
+	currentState.Is(Hungry).Dont(HaveFood)
+	goal := make(StateList)
+	goal.Isnt(Hungry)
+
+	request, err := pool.Submit(&Planner{}, &DefaultAgent{}, []Action{findFood(), eatAction()}, currentState, goal)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// changing the state after submitting doesn't affect the plan
+	currentState.Add(HaveFood)
+
+	plan := request.Wait()
+	if len(plan) != 2 || plan[0].String() != "getFood" {
+		t.Errorf("expected to get food and eat it, got %v", plan)
+	}
+	if _, ready := request.Result(); !ready {
+		t.Error("expected the request to be done")
+	}
+}
+
+func TestIdle_Pool(t *testing.T) {
+	pool := NewPlanPool(1, 1)
+	defer pool.Close()
+
+	agent := newManagedAgent()
+	agent.StateMachine.Pool = pool
+
+	agent.Update()
+	if agent.StateMachine.pending == nil {
+		t.Fatal("expected a plan request to be submitted")
+	}
+	agent.StateMachine.pending.Wait()
+	agent.Update()
+	if len(agent.CurrentActions()) != 1 {
+		t.Errorf("expected the plan to be picked up, got %v", agent.CurrentActions())
+	}
+}
+
+func TestIdle_Pool_stale(t *testing.T) {
+	pool := NewPlanPool(1, 1)
+	defer pool.Close()
+
+	agent := newManagedAgent()
+	agent.StateMachine.Pool = pool
+
+	agent.Update()
+	agent.StateMachine.pending.Wait()
+
+	// the goal changed while planning
+	agent.SetGoalState(StateList{"isWarm": true})
+	agent.Update()
+	if len(agent.CurrentActions()) != 0 {
+		t.Errorf("expected the stale plan to be thrown away, got %v", agent.CurrentActions())
+	}
+	if agent.StateMachine.pending != nil {
+		t.Error("expected the stale request to be cleared")
+	}
+}
+
+// blockingAgent holds up the worker planning for it until it's released
+type blockingAgent struct {
+	DefaultAgent
+	release chan struct{}
+}
+
+type blockingAction struct {
+	DefaultAction
+}
+
+func (a *blockingAction) CheckContextPrecondition(agent Agent) bool {
+	<-agent.(*blockingAgent).release
+	return true
+}
+
+func (a *blockingAction) Perform(agent Agent) bool {
+	return true
+}
+
+func TestPlanPool_busy_and_closed(t *testing.T) {
+	pool := NewPlanPool(1, 1)
+	agent := &blockingAgent{release: make(chan struct{})}
+	actions := []Action{&blockingAction{DefaultAction: NewAction("wait", 1)}}
+
+	first, err := pool.Submit(&Planner{}, agent, actions, make(StateList), make(StateList))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the worker is busy with the first request or the queue holds it, either way the pool fills up
+	var busy error
+	for i := 0; i < 3 && busy == nil; i++ {
+		_, busy = pool.Submit(&Planner{}, agent, actions, make(StateList), make(StateList))
+	}
+	if busy != ErrPoolBusy {
+		t.Errorf("expected the pool to be busy, got %v", busy)
+	}
+
+	close(agent.release)
+	first.Wait()
+	pool.Close()
+	if _, err := pool.Submit(&Planner{}, agent, actions, make(StateList), make(StateList)); err != ErrPoolClosed {
+		t.Errorf("expected the pool to be closed, got %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+package goap
+
+import (
+	"math"
+)
+
+// Vector is a position in the world.
+type Vector struct {
+	X, Y, Z float64
+}
+
+// Distance returns the distance between v and o.
+func (v Vector) Distance(o Vector) float64 {
+	dx, dy, dz := v.X-o.X, v.Y-o.Y, v.Z-o.Z
+	return math.Sqrt(dx*dx + dy*dy + dz*dz)
+}
+
+// Positioned is implemented by agents and targets that have a position in the world.
+type Positioned interface {
+	Position() Vector
+}
+
+// RangeChecker decides if the agent is close enough to the target to perform an action that has
+// the range radius.
+type RangeChecker func(agent Agent, target interface{}, radius float64) bool
+
+// DistanceRangeChecker is the RangeChecker used by DefaultAction unless another one is set. The
+// agent is in range if it is within radius of the target. Both the agent and the target must be
+// Positioned, but the target can also be a Vector. If the positions can't be found the agent is not
+// in range.
+func DistanceRangeChecker(agent Agent, target interface{}, radius float64) bool {
+	from, ok := positionOf(agent)
+	if !ok {
+		return false
+	}
+	to, ok := positionOf(target)
+	if !ok {
+		return false
+	}
+	return from.Distance(to) <= radius
+}
+
+func positionOf(v interface{}) (Vector, bool) {
+	switch p := v.(type) {
+	case Vector:
+		return p, true
+	case *Vector:
+		if p != nil {
+			return *p, true
+		}
+	case Positioned:
+		return p.Position(), true
+	}
+	return Vector{}, false
+}
+
+// TravelCost estimates the cost for the agent to move from one place to another. The places are
+// action targets, or the agent itself for where it currently is.
+type TravelCost func(agent Agent, from, to interface{}) float64
+
+// DistanceTravelCost is a TravelCost of the distance between the two places multiplied by
+// costPerUnit. Places must be Positioned or a Vector, travel to or from anywhere else is free.
+func DistanceTravelCost(costPerUnit float64) TravelCost {
+	return func(agent Agent, from, to interface{}) float64 {
+		a, ok := positionOf(from)
+		if !ok {
+			return 0
+		}
+		b, ok := positionOf(to)
+		if !ok {
+			return 0
+		}
+		return a.Distance(b) * costPerUnit
+	}
+}
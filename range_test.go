@@ -0,0 +1,133 @@
+package goap
+
+import (
+	"testing"
+)
+
+type positionedAgent struct {
+	DefaultAgent
+	position Vector
+	moves    int
+}
+
+func (a *positionedAgent) Position() Vector {
+	return a.position
+}
+
+func (a *positionedAgent) MoveAgent(next Action) bool {
+	a.moves++
+	a.position = next.Target().(Vector)
+	return true
+}
+
+func (a *positionedAgent) Update() {
+	a.FSM(a, func(string) {})
+}
+
+func TestDefaultAction_InRange(t *testing.T) {
+	agent := &positionedAgent{position: Vector{0, 0, 0}}
+
+	action := NewAction("chop", 1)
+	if !action.InRange(agent) {
+		t.Error("expected an action that doesn't require range to always be in range")
+	}
+
+	action.SetRequiresInRange(true)
+	action.SetRange(2)
+	if action.InRange(agent) {
+		t.Error("expected an action without a target to not be in range")
+	}
+
+	action.SetTarget(Vector{1, 1, 0})
+	if !action.InRange(agent) {
+		t.Error("expected the agent to be within range of the target")
+	}
+
+	action.SetTarget(Vector{3, 0, 0})
+	if action.InRange(agent) {
+		t.Error("expected the agent to be out of range of the target")
+	}
+
+	action.SetRangeChecker(func(Agent, interface{}, float64) bool { return true })
+	if !action.InRange(agent) {
+		t.Error("expected the range checker to be used")
+	}
+}
+
+func TestDo_range(t *testing.T) {
+	chop := newTestAction("chop", 1, true)
+	chop.SetTarget(Vector{10, 0, 0})
+	chop.AddEffect(State{"hasWood", true})
+
+	agent := &positionedAgent{DefaultAgent: NewDefaultAgent(nil)}
+	agent.SetState(make(StateList))
+	agent.SetGoalState(StateList{"hasWood": true})
+	agent.SetCurrentActions([]Action{chop})
+	agent.StateMachine.Reset(Do)
+
+	// schedule move, move, perform
+	for i := 0; i < 3; i++ {
+		agent.Update()
+	}
+	if agent.moves != 1 {
+		t.Errorf("expected the agent to move once to the target, moved %d times", agent.moves)
+	}
+
+	// range free actions never move
+	sleep := sleepAction()
+	agent.SetCurrentActions([]Action{sleep})
+	agent.Update()
+	if agent.moves != 1 {
+		t.Errorf("expected no moves for a range free action, moved %d times", agent.moves)
+	}
+}
+
+func TestPlanner_TravelCost(t *testing.T) {
+	cheapFar := newFetchAction("cheapFar", 1, Candidate{Target: Vector{100, 0, 0}})
+	cheapFar.AddEffect(HaveFood)
+	expensiveNear := newFetchAction("expensiveNear", 5, Candidate{Target: Vector{1, 0, 0}})
+	expensiveNear.AddEffect(HaveFood)
+
+	agent := &positionedAgent{}
+	actions := []Action{cheapFar, expensiveNear}
+
+	goal := make(StateList)
+	goal.Add(HaveFood)
+
+	actionList := (&Planner{}).Plan(agent, actions, make(StateList), goal)
+	if len(actionList) != 1 || Definition(actionList[0]) != cheapFar {
+		t.Errorf("expected the cheapest action without travel costs, got %v", actionList)
+	}
+
+	planner := &Planner{TravelCost: DistanceTravelCost(0.1)}
+	actionList = planner.Plan(agent, actions, make(StateList), goal)
+	if len(actionList) != 1 || Definition(actionList[0]) != expensiveNear {
+		t.Errorf("expected the nearby action when travel costs are added, got %v", actionList)
+	}
+}
+
+func TestPlanner_TravelCost_from_previous_target(t *testing.T) {
+	var legs [][2]interface{}
+	planner := &Planner{TravelCost: func(agent Agent, from, to interface{}) float64 {
+		legs = append(legs, [2]interface{}{from, to})
+		return 0
+	}}
+
+	walk := newFetchAction("walk", 1, Candidate{Target: Vector{5, 0, 0}})
+	walk.AddEffect(State{"atFood", true})
+	eat := newFetchAction("eat", 1, Candidate{Target: Vector{6, 0, 0}})
+	eat.AddPrecondition(State{"atFood", true})
+	eat.AddEffect(Isnt(Hungry))
+
+	agent := &positionedAgent{}
+	goal := make(StateList)
+	goal.Isnt(Hungry)
+	planner.Plan(agent, []Action{walk, eat}, make(StateList), goal)
+
+	if len(legs) != 2 {
+		t.Fatalf("expected two legs to be costed, got %v", legs)
+	}
+	if legs[0][0] != agent || legs[1][0] != (Vector{5, 0, 0}) {
+		t.Errorf("expected travel from the agent and then from the walk target, got %v", legs)
+	}
+}
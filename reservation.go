@@ -0,0 +1,110 @@
+package goap
+
+import (
+	"sync"
+)
+
+// NewReservations creates an empty registry where reservations last for timeout updates.
+func NewReservations(timeout int) *Reservations {
+	return &Reservations{
+		Timeout: timeout,
+		claims:  make(map[interface{}]reservation),
+	}
+}
+
+// Reservations lets agents claim targets, so that two agents don't plan to use the same one. It's
+// shared by all agents and safe to use from several goroutines.
+//
+// When a Planner has Reservations, targets that are reserved by another agent can't be planned
+// with, the targets of a found plan are reserved by the FSM, and they are released when the plan is
+// finished or aborted. Agents that are removed from the game should be released with ReleaseAll.
+//
+// Targets must be comparable to be reserved, other targets are always available.
+type Reservations struct {
+	// How many updates a reservation lasts, zero means until it's released. Reserving a target
+	// again renews it.
+	Timeout int
+
+	mu     sync.Mutex
+	now    int
+	claims map[interface{}]reservation
+}
+
+type reservation struct {
+	owner Agent
+	until int
+}
+
+// Tick advances the reservations one update and drops the ones that have expired. It should be
+// called once per game update.
+func (r *Reservations) Tick() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.now++
+	for target, claim := range r.claims {
+		if claim.until > 0 && claim.until <= r.now {
+			delete(r.claims, target)
+		}
+	}
+}
+
+// Reserve claims the target for the owner. It returns false if another agent has reserved it.
+func (r *Reservations) Reserve(target interface{}, owner Agent) bool {
+	if !isComparable(target) {
+		return true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if claim, found := r.claims[target]; found && claim.owner != owner {
+		return false
+	}
+	if r.claims == nil {
+		r.claims = make(map[interface{}]reservation)
+	}
+	claim := reservation{owner: owner}
+	if r.Timeout > 0 {
+		claim.until = r.now + r.Timeout
+	}
+	r.claims[target] = claim
+	return true
+}
+
+// Release the owner's reservation of the target.
+func (r *Reservations) Release(target interface{}, owner Agent) {
+	if !isComparable(target) {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if claim, found := r.claims[target]; found && claim.owner == owner {
+		delete(r.claims, target)
+	}
+}
+
+// ReleaseAll releases all the owner's reservations.
+func (r *Reservations) ReleaseAll(owner Agent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for target, claim := range r.claims {
+		if claim.owner == owner {
+			delete(r.claims, target)
+		}
+	}
+}
+
+// Owner returns the agent that has reserved the target, and false if it isn't reserved.
+func (r *Reservations) Owner(target interface{}) (Agent, bool) {
+	if !isComparable(target) {
+		return nil, false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	claim, found := r.claims[target]
+	return claim.owner, found
+}
+
+// Available returns true if the target isn't reserved, or is reserved by the agent.
+func (r *Reservations) Available(target interface{}, agent Agent) bool {
+	owner, found := r.Owner(target)
+	return !found || owner == agent
+}
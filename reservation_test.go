@@ -0,0 +1,75 @@
+package goap
+
+import (
+	"testing"
+)
+
+func TestReservations(t *testing.T) {
+	a := &DefaultAgent{}
+	b := &DefaultAgent{}
+	r := NewReservations(0)
+
+	if !r.Reserve("medkit", a) {
+		t.Fatal("expected to reserve the medkit")
+	}
+	if r.Reserve("medkit", b) {
+		t.Error("expected the medkit to be reserved by a")
+	}
+	if !r.Available("medkit", a) || r.Available("medkit", b) {
+		t.Error("expected the medkit to only be available to a")
+	}
+
+	r.Release("medkit", b)
+	if owner, _ := r.Owner("medkit"); owner != a {
+		t.Error("expected b not to be able to release a's reservation")
+	}
+
+	r.ReleaseAll(a)
+	if !r.Reserve("medkit", b) {
+		t.Error("expected the medkit to be released")
+	}
+}
+
+func TestReservations_Timeout(t *testing.T) {
+	a := &DefaultAgent{}
+	r := NewReservations(2)
+	r.Reserve("medkit", a)
+
+	r.Tick()
+	if _, found := r.Owner("medkit"); !found {
+		t.Error("expected the reservation to still be there")
+	}
+	r.Tick()
+	if _, found := r.Owner("medkit"); found {
+		t.Error("expected the reservation to expire")
+	}
+}
+
+func TestReservations_FSM(t *testing.T) {
+	reservations := NewReservations(0)
+	medkits := []Candidate{{Target: "medkit1", Cost: 1}, {Target: "medkit2", Cost: 5}}
+
+	newHealer := func() *recordingAgent {
+		heal := newFetchAction("heal", 1, medkits...)
+		heal.AddEffect(State{"healed", true})
+		agent := newRecordingAgent(heal)
+		agent.SetGoalState(StateList{"healed": true})
+		agent.StateMachine.Planner = &Planner{Reservations: reservations}
+		return agent
+	}
+	a := newHealer()
+	b := newHealer()
+
+	a.Update()
+	b.Update()
+	targetA, targetB := a.CurrentActions()[0].Target(), b.CurrentActions()[0].Target()
+	if targetA != "medkit1" || targetB != "medkit2" {
+		t.Errorf("expected the agents to reserve different medkits, got %v and %v", targetA, targetB)
+	}
+
+	// a gives up, which frees medkit1 for the next plan
+	a.StateMachine.abort(a, a.CurrentActions()[0], ErrStuck)
+	if !reservations.Available("medkit1", b) {
+		t.Error("expected the medkit to be released when the plan is aborted")
+	}
+}
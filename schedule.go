@@ -0,0 +1,21 @@
+package goap
+
+// Step is an action in a plan together with when it is expected to start and finish.
+type Step struct {
+	Action Action
+	Start  float64
+	End    float64
+}
+
+// Schedule returns the expected start and completion time of each action in the plan, when the plan
+// starts at the time start. Actions are performed one after the other, so each step starts when the
+// previous one ends.
+func Schedule(start float64, plan []Action) []Step {
+	steps := make([]Step, len(plan))
+	for i, action := range plan {
+		end := start + action.Duration()
+		steps[i] = Step{Action: action, Start: start, End: end}
+		start = end
+	}
+	return steps
+}
@@ -0,0 +1,292 @@
+package goap
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Param is a typed parameter of a Schema, for example Param{"?item", "item"}. Parameter names
+// start with a question mark.
+type Param struct {
+	Name string
+	Type string
+}
+
+// Objects lists the objects in a world by their type, for example {"item": {"apple", "axe"}}.
+type Objects map[string][]string
+
+// Binding maps the variables of a Schema to objects.
+type Binding map[string]string
+
+// Schema describes a family of actions with parameters, for example pickup(?item) with the
+// precondition at(?item,?loc). The states of a schema use predicates, state names of the form
+// "name(arg1,arg2)" where arguments starting with a question mark are variables. Ground turns a
+// schema into one concrete action for each way its variables can be bound to objects, before
+// planning, see Ground for how variables that aren't parameters are bound.
+type Schema struct {
+	Name          string
+	Cost          float64
+	Params        []Param
+	Preconditions []State
+	Effects       []State
+
+	// Perform is called when a grounded action is performed. If nil, the action succeeds and is
+	// done straight away.
+	Perform func(agent Agent, action *GroundAction) bool
+}
+
+// Ground creates the concrete actions of the schema. The parameters are bound to every combination
+// of objects of the right type. Variables that only appear in the preconditions, like ?loc in
+// at(?item,?loc), are bound by matching the preconditions against the facts that can become true:
+// the true facts in the world state, and the true effects of the actions grounded from those, until
+// no new facts turn up. If the parameters are bound to the same objects more than once, the free
+// variables are added to the name of the action, like pickup(apple,kitchen).
+func (s *Schema) Ground(objects Objects, state StateList) []Action {
+	return GroundAll([]*Schema{s}, objects, state)
+}
+
+// GroundAll grounds all schemas against the same objects and world state. The facts that the
+// actions of one schema can make true are used to bind the free variables of all of them, so that
+// an item that one action moves can be picked up by another at its new place.
+func GroundAll(schemas []*Schema, objects Objects, state StateList) []Action {
+	facts := reachableFacts(schemas, objects, state)
+	var actions []Action
+	for _, s := range schemas {
+		actions = append(actions, s.groundFacts(objects, facts)...)
+	}
+	return actions
+}
+
+// reachableFacts returns the true facts in the state together with the facts that the schemas can
+// make true from them
+func reachableFacts(schemas []*Schema, objects Objects, state StateList) StateList {
+	facts := make(StateList)
+	for fact, value := range state {
+		if value {
+			facts[fact] = true
+		}
+	}
+	for added := true; added; {
+		added = false
+		for _, s := range schemas {
+			for _, action := range s.groundFacts(objects, facts) {
+				for effect, value := range action.Effects() {
+					if value && !facts[effect] {
+						facts[effect] = true
+						added = true
+					}
+				}
+			}
+		}
+	}
+	return facts
+}
+
+// groundFacts creates the actions of the schema with the free variables bound to the facts
+func (s *Schema) groundFacts(objects Objects, facts StateList) []Action {
+	var actions []Action
+	for _, binding := range s.bindParams(objects, 0, make(Binding)) {
+		bindings := s.bindFree(facts, 0, binding)
+		for _, b := range bindings {
+			if action := s.ground(b, len(bindings) > 1); action != nil {
+				actions = append(actions, action)
+			}
+		}
+	}
+	return actions
+}
+
+// bindParams returns every binding of the parameters from i onwards to objects of their type
+func (s *Schema) bindParams(objects Objects, i int, binding Binding) []Binding {
+	if i == len(s.Params) {
+		return []Binding{copyBinding(binding)}
+	}
+	var result []Binding
+	param := s.Params[i]
+	for _, object := range objects[param.Type] {
+		binding[param.Name] = object
+		result = append(result, s.bindParams(objects, i+1, binding)...)
+	}
+	delete(binding, param.Name)
+	return result
+}
+
+// bindFree binds the variables left in the true preconditions from i onwards to facts in the state
+func (s *Schema) bindFree(state StateList, i int, binding Binding) []Binding {
+	if i == len(s.Preconditions) {
+		return []Binding{binding}
+	}
+	pre := s.Preconditions[i]
+	if !pre.Value || isGround(substitute(pre.Name, binding)) {
+		return s.bindFree(state, i+1, binding)
+	}
+	// go through the facts in order so that grounding always gives the same actions
+	var facts []string
+	for fact, value := range state {
+		if value {
+			facts = append(facts, fact)
+		}
+	}
+	sort.Strings(facts)
+
+	var result []Binding
+	for _, fact := range facts {
+		if b, ok := unify(pre.Name, fact, binding); ok {
+			result = append(result, s.bindFree(state, i+1, b)...)
+		}
+	}
+	return result
+}
+
+// ground creates the action for a binding, or nil if some variables are still unbound. The free
+// variables are added to the name if named is true.
+func (s *Schema) ground(binding Binding, named bool) Action {
+	a := &GroundAction{
+		DefaultAction: NewAction(s.actionName(binding, named), s.Cost),
+		Schema:        s,
+		Binding:       binding,
+	}
+	for _, pre := range s.Preconditions {
+		name := substitute(pre.Name, binding)
+		if !isGround(name) {
+			return nil
+		}
+		a.AddPrecondition(State{name, pre.Value})
+	}
+	for _, effect := range s.Effects {
+		name := substitute(effect.Name, binding)
+		if !isGround(name) {
+			return nil
+		}
+		a.AddEffect(State{name, effect.Value})
+	}
+	return a
+}
+
+func (s *Schema) actionName(binding Binding, free bool) string {
+	var args []string
+	seen := make(map[string]bool)
+	for _, param := range s.Params {
+		args = append(args, binding[param.Name])
+		seen[param.Name] = true
+	}
+	if free {
+		// in the order they first show up in the preconditions
+		for _, pre := range s.Preconditions {
+			_, vars := ParsePredicate(pre.Name)
+			for _, v := range vars {
+				if isVariable(v) && !seen[v] {
+					args = append(args, binding[v])
+					seen[v] = true
+				}
+			}
+		}
+	}
+	if len(args) == 0 {
+		return s.Name
+	}
+	return Predicate(s.Name, args...)
+}
+
+// GroundAction is an action created by grounding a Schema.
+type GroundAction struct {
+	DefaultAction
+	Schema  *Schema
+	Binding Binding
+}
+
+func (a *GroundAction) Perform(agent Agent) bool {
+	if a.Schema.Perform == nil {
+		a.Done = true
+		return true
+	}
+	return a.Schema.Perform(agent, a)
+}
+
+// Predicate builds a state name like "at(apple,kitchen)".
+func Predicate(name string, args ...string) string {
+	if len(args) == 0 {
+		return name
+	}
+	return fmt.Sprintf("%s(%s)", name, strings.Join(args, ","))
+}
+
+// ParsePredicate splits a state name like "at(apple,kitchen)" into its name and arguments.
+func ParsePredicate(s string) (name string, args []string) {
+	open := strings.IndexByte(s, '(')
+	if open < 0 || !strings.HasSuffix(s, ")") {
+		return strings.TrimSpace(s), nil
+	}
+	name = strings.TrimSpace(s[:open])
+	inner := strings.TrimSpace(s[open+1 : len(s)-1])
+	if inner == "" {
+		return name, nil
+	}
+	for _, arg := range strings.Split(inner, ",") {
+		args = append(args, strings.TrimSpace(arg))
+	}
+	return name, args
+}
+
+func isVariable(arg string) bool {
+	return strings.HasPrefix(arg, "?")
+}
+
+// isGround returns true if the predicate has no variables
+func isGround(predicate string) bool {
+	_, args := ParsePredicate(predicate)
+	for _, arg := range args {
+		if isVariable(arg) {
+			return false
+		}
+	}
+	return true
+}
+
+// substitute replaces the bound variables in the predicate
+func substitute(predicate string, binding Binding) string {
+	name, args := ParsePredicate(predicate)
+	if len(args) == 0 {
+		return predicate
+	}
+	for i, arg := range args {
+		if object, ok := binding[arg]; ok {
+			args[i] = object
+		}
+	}
+	return Predicate(name, args...)
+}
+
+// unify matches the pattern against a ground fact and returns the binding extended with the
+// variables it bound
+func unify(pattern, fact string, binding Binding) (Binding, bool) {
+	pName, pArgs := ParsePredicate(pattern)
+	fName, fArgs := ParsePredicate(fact)
+	if pName != fName || len(pArgs) != len(fArgs) {
+		return nil, false
+	}
+	result := copyBinding(binding)
+	for i, arg := range pArgs {
+		if isVariable(arg) {
+			if object, ok := result[arg]; ok {
+				arg = object
+			} else {
+				result[arg] = fArgs[i]
+				continue
+			}
+		}
+		if arg != fArgs[i] {
+			return nil, false
+		}
+	}
+	return result, true
+}
+
+func copyBinding(b Binding) Binding {
+	c := make(Binding, len(b))
+	for k, v := range b {
+		c[k] = v
+	}
+	return c
+}
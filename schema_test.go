@@ -0,0 +1,115 @@
+package goap
+
+import (
+	"testing"
+)
+
+func pickupSchema() *Schema {
+	return &Schema{
+		Name:   "pickup",
+		Cost:   1,
+		Params: []Param{{"?item", "item"}},
+		Preconditions: []State{
+			{Predicate("at", "?item", "?loc"), true},
+			{Predicate("agentAt", "?loc"), true},
+		},
+		Effects: []State{
+			{Predicate("holding", "?item"), true},
+			{Predicate("at", "?item", "?loc"), false},
+		},
+	}
+}
+
+func TestSchema_Ground(t *testing.T) {
+	objects := Objects{"item": {"apple", "axe"}}
+
+	state := make(StateList)
+	state[Predicate("at", "apple", "kitchen")] = true
+	state[Predicate("at", "axe", "shed")] = true
+	state[Predicate("agentAt", "kitchen")] = true
+
+	actions := pickupSchema().Ground(objects, state)
+	if len(actions) != 2 {
+		t.Fatalf("expected 2 grounded actions, got %d: %v", len(actions), actions)
+	}
+
+	apple := actions[0].(*GroundAction)
+	if apple.String() != "pickup(apple)" {
+		t.Errorf("expected 'pickup(apple)', got %s", apple)
+	}
+	if apple.Binding["?loc"] != "kitchen" {
+		t.Errorf("expected ?loc to be bound to kitchen, got %s", apple.Binding["?loc"])
+	}
+	if !apple.Preconditions()["at(apple,kitchen)"] || !apple.Preconditions()["agentAt(kitchen)"] {
+		t.Errorf("unexpected preconditions %v", apple.Preconditions())
+	}
+	if !apple.Effects()["holding(apple)"] {
+		t.Errorf("unexpected effects %v", apple.Effects())
+	}
+}
+
+func TestSchema_Plan(t *testing.T) {
+	move := &Schema{
+		Name:          "move",
+		Cost:          1,
+		Params:        []Param{{"?from", "loc"}, {"?to", "loc"}},
+		Preconditions: []State{{Predicate("agentAt", "?from"), true}},
+		Effects: []State{
+			{Predicate("agentAt", "?from"), false},
+			{Predicate("agentAt", "?to"), true},
+		},
+	}
+	objects := Objects{"item": {"axe"}, "loc": {"kitchen", "shed"}}
+
+	state := make(StateList)
+	state[Predicate("at", "axe", "shed")] = true
+	state[Predicate("agentAt", "kitchen")] = true
+
+	goal := StateList{Predicate("holding", "axe"): true}
+
+	actions := GroundAll([]*Schema{move, pickupSchema()}, objects, state)
+	plan := Plan(&DefaultAgent{}, actions, state, goal)
+
+	if len(plan) != 2 || plan[0].String() != "move(kitchen,shed)" || plan[1].String() != "pickup(axe)" {
+		t.Errorf("expected to move to the shed and pick up the axe, got %v", plan)
+	}
+}
+
+func TestSchema_Plan_moved(t *testing.T) {
+	fetch := &Schema{
+		Name:          "fetch",
+		Cost:          1,
+		Params:        []Param{{"?item", "item"}, {"?to", "loc"}},
+		Preconditions: []State{{Predicate("at", "?item", "?from"), true}},
+		Effects: []State{
+			{Predicate("at", "?item", "?from"), false},
+			{Predicate("at", "?item", "?to"), true},
+		},
+	}
+	objects := Objects{"item": {"axe"}, "loc": {"kitchen", "shed"}}
+
+	state := make(StateList)
+	state[Predicate("at", "axe", "shed")] = true
+	state[Predicate("agentAt", "kitchen")] = true
+
+	goal := StateList{Predicate("holding", "axe"): true}
+
+	actions := GroundAll([]*Schema{fetch, pickupSchema()}, objects, state)
+	plan := Plan(&DefaultAgent{}, actions, state, goal)
+
+	if len(plan) != 2 || plan[0].String() != "fetch(axe,kitchen,shed)" || plan[1].String() != "pickup(axe,kitchen)" {
+		t.Errorf("expected to fetch the axe to the kitchen and pick it up, got %v", plan)
+	}
+}
+
+func TestParsePredicate(t *testing.T) {
+	name, args := ParsePredicate("at( apple, kitchen )")
+	if name != "at" || len(args) != 2 || args[0] != "apple" || args[1] != "kitchen" {
+		t.Errorf("unexpected parse result %s %v", name, args)
+	}
+
+	name, args = ParsePredicate("hasFood")
+	if name != "hasFood" || args != nil {
+		t.Errorf("unexpected parse result %s %v", name, args)
+	}
+}
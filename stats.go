@@ -0,0 +1,32 @@
+package goap
+
+import (
+	"sync/atomic"
+)
+
+// PlanStats counts the work done by a Planner, it's safe to share between goroutines.
+type PlanStats struct {
+	plans int64
+	nodes int64
+}
+
+// Plans returns how many times Plan has been called.
+func (s *PlanStats) Plans() int64 {
+	return atomic.LoadInt64(&s.plans)
+}
+
+// Nodes returns how many nodes the searches have expanded. A plan found in the cache expands none.
+func (s *PlanStats) Nodes() int64 {
+	return atomic.LoadInt64(&s.nodes)
+}
+
+// Reset sets the counts to zero.
+func (s *PlanStats) Reset() {
+	atomic.StoreInt64(&s.plans, 0)
+	atomic.StoreInt64(&s.nodes, 0)
+}
+
+func (s *PlanStats) add(plans, nodes int64) {
+	atomic.AddInt64(&s.plans, plans)
+	atomic.AddInt64(&s.nodes, nodes)
+}
@@ -0,0 +1,32 @@
+package goap
+
+import (
+	"testing"
+)
+
+func TestPlanStats(t *testing.T) {
+	actions := []Action{findFood(), eatAction()}
+
+	currentState := make(StateList)
+	currentState.Is(Hungry).Dont(HaveFood)
+
+	goal := make(StateList)
+	goal.Isnt(Hungry)
+
+	planner := &Planner{Stats: &PlanStats{}, Cache: NewPlanCache(1)}
+	planner.Plan(&DefaultAgent{}, actions, currentState, goal)
+	nodes := planner.Stats.Nodes()
+	if nodes != 2 {
+		t.Errorf("expected getFood and eat to be expanded, got %d nodes", nodes)
+	}
+
+	planner.Plan(&DefaultAgent{}, actions, currentState, goal)
+	if planner.Stats.Plans() != 2 || planner.Stats.Nodes() != nodes {
+		t.Errorf("expected the cached plan to expand no nodes, got %d plans and %d nodes", planner.Stats.Plans(), planner.Stats.Nodes())
+	}
+
+	planner.Stats.Reset()
+	if planner.Stats.Plans() != 0 || planner.Stats.Nodes() != 0 {
+		t.Error("expected the stats to be reset")
+	}
+}
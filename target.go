@@ -0,0 +1,18 @@
+package goap
+
+// Candidate is a target that an action can be planned with, and the extra cost of using it.
+type Candidate struct {
+	Target interface{}
+	Cost   float64
+}
+
+// TargetProvider is implemented by actions that let the planner choose their target. Instead of
+// setting a target in CheckContextPrecondition, the action lists the targets it could use and the
+// planner picks the one that gives the cheapest plan. The chosen target is set with SetTarget on the
+// actions in the returned plan.
+//
+// An action that returns no candidates is left out of planning. Candidates without a target are
+// left out for actions that require range.
+type TargetProvider interface {
+	Targets(agent Agent) []Candidate
+}
@@ -0,0 +1,88 @@
+package goap
+
+import (
+	"testing"
+)
+
+func newFetchAction(name string, cost float64, targets ...Candidate) *fetchAction {
+	a := &fetchAction{
+		DefaultAction: NewAction(name, cost),
+		targets:       targets,
+	}
+	a.SetRequiresInRange(true)
+	return a
+}
+
+// fetchAction lets the planner choose which target to fetch
+type fetchAction struct {
+	DefaultAction
+	targets []Candidate
+}
+
+func (a *fetchAction) Targets(agent Agent) []Candidate {
+	return a.targets
+}
+
+func (a *fetchAction) Perform(agent Agent) bool {
+	return true
+}
+
+func TestPlan_TargetProvider(t *testing.T) {
+	fetch := newFetchAction("fetch", 2, Candidate{"far apple", 10}, Candidate{"near apple", 1})
+	fetch.AddEffect(HaveFood)
+
+	goal := make(StateList)
+	goal.Add(HaveFood)
+
+	actionList := Plan(&DefaultAgent{}, []Action{fetch}, make(StateList), goal)
+	if len(actionList) != 1 {
+		t.Fatalf("expected a plan with one action, got %v", actionList)
+	}
+	if actionList[0].Target() != "near apple" {
+		t.Errorf("expected the cheapest target to be bound, got %v", actionList[0].Target())
+	}
+}
+
+func TestPlan_TargetProvider_cheapest_overall(t *testing.T) {
+	fetch := newFetchAction("fetch", 2, Candidate{"far apple", 10})
+	fetch.AddEffect(HaveFood)
+
+	currentState := make(StateList)
+	currentState.Dont(HaveFood)
+
+	goal := make(StateList)
+	goal.Add(HaveFood)
+
+	actionList := Plan(&DefaultAgent{}, []Action{fetch, findFood()}, currentState, goal)
+	if len(actionList) != 1 || actionList[0].String() != "getFood" {
+		t.Errorf("expected the target cost to make getFood cheaper, got %v", actionList)
+	}
+}
+
+func TestPlan_no_target(t *testing.T) {
+	// needs to move to its target, but has none
+	fetch := newFetchAction("fetch", 2)
+	fetch.AddEffect(HaveFood)
+
+	goal := make(StateList)
+	goal.Add(HaveFood)
+
+	actionList := Plan(&DefaultAgent{}, []Action{fetch}, make(StateList), goal)
+	if actionList != nil {
+		t.Errorf("expected no plan without a target, got %v", actionList)
+	}
+}
+
+func TestPlan_nil_candidate(t *testing.T) {
+	// the nil candidate is cheaper, but fetch can't be moved to it
+	fetch := newFetchAction("fetch", 2, Candidate{nil, 0}, Candidate{"apple", 5})
+	fetch.AddEffect(HaveFood)
+
+	goal := make(StateList)
+	goal.Add(HaveFood)
+
+	actionList := Plan(&DefaultAgent{}, []Action{fetch}, make(StateList), goal)
+	if len(actionList) != 1 || actionList[0].Target() != "apple" {
+		t.Errorf("expected fetch to be planned with the apple, got %v", actionList)
+	}
+}
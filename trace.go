@@ -0,0 +1,157 @@
+package goap
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// SearchTrace records the nodes that a search expanded, so that it can be seen why a plan was
+// chosen. See Planner.PlanTrace.
+type SearchTrace struct {
+	// The nodes in the order they were expanded, the first one is the root.
+	Nodes []TraceNode
+	// The search went from the goal to the world state. The states of the nodes are then what is
+	// needed before their action, and the root is the goal.
+	Backward bool
+}
+
+// TraceNode is one node of a search.
+type TraceNode struct {
+	// The index of the parent node, -1 for the root.
+	Parent int
+	// The action that leads from the parent to this node and its target, nil for the root.
+	Action Action
+	Target interface{}
+	// The state after the action, or what is needed before it when searching backward.
+	State   StateList
+	Cost    float64
+	Elapsed float64
+	// Leaf is true if the node is a solution, and Chosen if it's on the path of the chosen plan.
+	Leaf   bool
+	Chosen bool
+}
+
+// PlanTrace plans like Plan and also returns a trace of the search. The cache isn't used, so that
+// there is always a search to trace. The trace has no nodes if no action could be used.
+func (p *Planner) PlanTrace(agent Agent, availableActions []Action, worldState StateList, goal StateList) ([]Action, *SearchTrace) {
+	s := p.newSearch(agent)
+	s.trace = &SearchTrace{}
+	plan := s.plan(availableActions, worldState, goal)
+	if p.Stats != nil {
+		p.Stats.add(1, int64(s.nodes))
+	}
+	return plan, s.trace
+}
+
+// record the nodes of the search in the trace, they are in the arena in the order they were made
+func (s *search) record(leaves []*node, chosen *node) {
+	s.trace.Backward = s.backward()
+	names := make([]string, len(s.index.bits))
+	for name, bit := range s.index.bits {
+		names[bit] = name
+	}
+
+	index := make(map[*node]int, s.arena.used)
+	for i := 0; i < s.arena.used; i++ {
+		n := &s.arena.nodes[i/arenaChunk][i%arenaChunk]
+		index[n] = i
+		t := TraceNode{
+			Parent:  -1,
+			Action:  n.action,
+			Target:  n.target,
+			State:   make(StateList),
+			Cost:    n.runningCost,
+			Elapsed: n.elapsed,
+		}
+		if n.parent != nil {
+			t.Parent = index[n.parent]
+		}
+		known, values := n.state.known(), n.state.values()
+		for bit, name := range names {
+			if known[bit/64]&(1<<(bit%64)) != 0 {
+				t.State[name] = values[bit/64]&(1<<(bit%64)) != 0
+			}
+		}
+		s.trace.Nodes = append(s.trace.Nodes, t)
+	}
+	for _, leaf := range leaves {
+		s.trace.Nodes[index[leaf]].Leaf = true
+	}
+	for n := chosen; n != nil; n = n.parent {
+		s.trace.Nodes[index[n]].Chosen = true
+	}
+}
+
+// WriteDOT writes the search tree in the Graphviz DOT format. The root is labelled with its full
+// state and the other nodes with what changed from their parent, together with the running cost.
+// Edges are labelled with the actions and their targets, solutions have a double border and the
+// path of the chosen plan is drawn in bold blue.
+func (t *SearchTrace) WriteDOT(w io.Writer) error {
+	b := bufio.NewWriter(w)
+	fmt.Fprintln(b, "digraph search {")
+	fmt.Fprintln(b, "  node [shape=box];")
+	for i, n := range t.Nodes {
+		var label string
+		if n.Parent < 0 {
+			label = "goal"
+			if !t.Backward {
+				label = "start"
+			}
+			label += "\n" + stateDiff(nil, n.State)
+		} else {
+			label = stateDiff(t.Nodes[n.Parent].State, n.State)
+		}
+		label += fmt.Sprintf("\ncost %g", n.Cost)
+
+		attrs := []string{"label=" + quoteDOT(label)}
+		if n.Leaf {
+			attrs = append(attrs, "peripheries=2")
+		}
+		if n.Chosen {
+			attrs = append(attrs, "color=blue", "penwidth=2")
+		}
+		fmt.Fprintf(b, "  n%d [%s];\n", i, strings.Join(attrs, ", "))
+
+		if n.Parent >= 0 {
+			edge := n.Action.String()
+			if n.Target != nil {
+				edge += fmt.Sprintf(" @ %v", n.Target)
+			}
+			attrs := []string{"label=" + quoteDOT(edge)}
+			if n.Chosen {
+				attrs = append(attrs, "color=blue", "penwidth=2")
+			}
+			fmt.Fprintf(b, "  n%d -> n%d [%s];\n", n.Parent, i, strings.Join(attrs, ", "))
+		}
+	}
+	fmt.Fprintln(b, "}")
+	return b.Flush()
+}
+
+// stateDiff lists the names that are set differently in state than in parent, sorted, with a ! in
+// front of the ones that are false
+func stateDiff(parent, state StateList) string {
+	var diff []string
+	for name, value := range state {
+		if old, found := parent[name]; found && old == value {
+			continue
+		}
+		if value {
+			diff = append(diff, name)
+		} else {
+			diff = append(diff, "!"+name)
+		}
+	}
+	sort.Slice(diff, func(i, j int) bool {
+		return strings.TrimPrefix(diff[i], "!") < strings.TrimPrefix(diff[j], "!")
+	})
+	return strings.Join(diff, ", ")
+}
+
+func quoteDOT(s string) string {
+	s = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+	return `"` + s + `"`
+}
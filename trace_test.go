@@ -0,0 +1,77 @@
+package goap
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestPlanner_PlanTrace(t *testing.T) {
+	pray := newTestAction("prayForFood", 10, false)
+	pray.AddEffect(HaveFood)
+	pray.AddPrecondition(Dont(HaveFood))
+	actions := []Action{findFood(), pray, eatAction()}
+
+	currentState := make(StateList)
+	currentState.Is(Hungry).Dont(HaveFood)
+
+	goal := make(StateList)
+	goal.Isnt(Hungry)
+
+	planner := &Planner{Stats: &PlanStats{}}
+	plan, trace := planner.PlanTrace(&DefaultAgent{}, actions, currentState, goal)
+	if len(plan) != 2 || plan[0].String() != "getFood" {
+		t.Fatalf("expected getFood and eat, got %v", plan)
+	}
+	// the root, getFood and prayForFood, and eat after each of them
+	if len(trace.Nodes) != 5 || int64(len(trace.Nodes)) != planner.Stats.Nodes()+1 {
+		t.Fatalf("expected 5 nodes, got %d", len(trace.Nodes))
+	}
+
+	leaves, chosen := 0, 0
+	for _, n := range trace.Nodes {
+		if n.Leaf {
+			leaves++
+		}
+		if n.Chosen {
+			chosen++
+			if n.Action != nil && n.Action != plan[0] && n.Action != plan[1] {
+				t.Errorf("expected only the plan's actions to be chosen, got %s", n.Action)
+			}
+		}
+	}
+	if leaves != 2 || chosen != 3 {
+		t.Errorf("expected 2 leaves and a chosen path of 3 nodes, got %d and %d", leaves, chosen)
+	}
+
+	var dot bytes.Buffer
+	if err := trace.WriteDOT(&dot); err != nil {
+		t.Fatal(err)
+	}
+	for _, expected := range []string{
+		`n0 [label="start\n!hasFood, !isFull\ncost 0", color=blue, penwidth=2];`,
+		`[label="hasFood\ncost 8", color=blue, penwidth=2];`,
+		`n0 -> n1 [label="getFood", color=blue, penwidth=2];`,
+		`peripheries=2`,
+	} {
+		if !strings.Contains(dot.String(), expected) {
+			t.Errorf("expected the DOT output to contain %s, got\n%s", expected, dot.String())
+		}
+	}
+}
+
+func TestPlanner_PlanTrace_no_plan(t *testing.T) {
+	goal := make(StateList)
+	goal.Isnt(Hungry)
+
+	currentState := make(StateList)
+	currentState.Dont(HaveFood)
+
+	plan, trace := (&Planner{}).PlanTrace(&DefaultAgent{}, []Action{findFood()}, currentState, goal)
+	if plan != nil {
+		t.Errorf("expected no plan, got %v", plan)
+	}
+	if len(trace.Nodes) != 2 || trace.Nodes[1].Leaf || trace.Nodes[1].Chosen {
+		t.Errorf("expected the root and getFood without a solution, got %+v", trace.Nodes)
+	}
+}
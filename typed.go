@@ -0,0 +1,85 @@
+package goap
+
+// TypedAction is an Action whose target has a known type, so that actions and MoveAgent
+// implementations don't need type assertions to use it.
+type TypedAction[T any] interface {
+	Action
+	SetTypedTarget(T)
+	// TypedTarget returns the target, and false if it hasn't been set.
+	TypedTarget() (T, bool)
+}
+
+// NewTypedAction create a new TypedDefaultAction
+func NewTypedAction[T any](name string, cost float64) TypedDefaultAction[T] {
+	return TypedDefaultAction[T]{
+		DefaultAction: NewAction(name, cost),
+	}
+}
+
+// TypedDefaultAction is a DefaultAction whose target is always of type T. It can be mixed with
+// actions of other target types in the same plan.
+type TypedDefaultAction[T any] struct {
+	DefaultAction
+}
+
+// SetTarget sets the target. A target that is not nil and not a T is ignored, the planner leaves
+// such candidates out so they are never set by it.
+func (a *TypedDefaultAction[T]) SetTarget(t interface{}) {
+	if t == nil {
+		a.DefaultAction.SetTarget(nil)
+		return
+	}
+	if typed, ok := t.(T); ok {
+		a.SetTypedTarget(typed)
+	}
+}
+
+func (a *TypedDefaultAction[T]) acceptsTarget(t interface{}) bool {
+	_, ok := t.(T)
+	return t == nil || ok
+}
+
+func (a *TypedDefaultAction[T]) SetTypedTarget(t T) {
+	a.DefaultAction.SetTarget(t)
+}
+
+func (a *TypedDefaultAction[T]) TypedTarget() (T, bool) {
+	t, ok := a.Target().(T)
+	return t, ok
+}
+
+// targetAcceptor is implemented by actions that only take targets of some type
+type targetAcceptor interface {
+	acceptsTarget(interface{}) bool
+}
+
+// acceptsTarget returns false if the action, or the action that is assigned, can't take the target
+func acceptsTarget(action Action, target interface{}) bool {
+	if a, ok := action.(*Assignment); ok {
+		action = a.Action
+	}
+	if t, ok := action.(targetAcceptor); ok {
+		return t.acceptsTarget(target)
+	}
+	return true
+}
+
+// TargetOf returns the target of any action as a T. It returns false if the action has no target or
+// the target is not a T.
+func TargetOf[T any](action Action) (T, bool) {
+	t, ok := action.Target().(T)
+	return t, ok
+}
+
+// TypedCandidates turns a list of targets into candidates for TargetProvider.Targets, with the cost
+// of each target given by cost. A nil cost function gives every target a cost of zero.
+func TypedCandidates[T any](targets []T, cost func(T) float64) []Candidate {
+	candidates := make([]Candidate, len(targets))
+	for i, t := range targets {
+		candidates[i].Target = t
+		if cost != nil {
+			candidates[i].Cost = cost(t)
+		}
+	}
+	return candidates
+}
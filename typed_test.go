@@ -0,0 +1,125 @@
+package goap
+
+import (
+	"testing"
+)
+
+type point struct {
+	x, y int
+}
+
+func newWalkAction(targets ...point) *walkAction {
+	return &walkAction{
+		TypedDefaultAction: NewTypedAction[point]("walk", 1),
+		targets:            targets,
+	}
+}
+
+type walkAction struct {
+	TypedDefaultAction[point]
+	targets []point
+}
+
+func (a *walkAction) Targets(agent Agent) []Candidate {
+	return TypedCandidates(a.targets, func(p point) float64 {
+		return float64(p.x + p.y)
+	})
+}
+
+func (a *walkAction) Perform(agent Agent) bool {
+	a.Done = true
+	return true
+}
+
+func (a *walkAction) InRange(agent Agent) bool {
+	return true
+}
+
+func newTypedEatAction(food string) *typedEatAction {
+	return &typedEatAction{
+		TypedDefaultAction: NewTypedAction[string]("eat", 1),
+		food:               food,
+	}
+}
+
+type typedEatAction struct {
+	TypedDefaultAction[string]
+	food string
+}
+
+func (a *typedEatAction) CheckContextPrecondition(agent Agent) bool {
+	a.SetTypedTarget(a.food)
+	return true
+}
+
+func (a *typedEatAction) Perform(agent Agent) bool {
+	return true
+}
+
+func (a *typedEatAction) InRange(agent Agent) bool {
+	return true
+}
+
+func TestTypedAction_mixed_targets(t *testing.T) {
+	walk := newWalkAction(point{5, 5}, point{1, 2})
+	walk.AddEffect(State{"atFood", true})
+
+	eat := newTypedEatAction("apple")
+	eat.AddPrecondition(State{"atFood", true})
+	eat.AddEffect(Isnt(Hungry))
+
+	goal := make(StateList)
+	goal.Isnt(Hungry)
+
+	actionList := Plan(&DefaultAgent{}, []Action{walk, eat}, make(StateList), goal)
+	if len(actionList) != 2 {
+		t.Fatalf("expected two actions in the plan, got %v", actionList)
+	}
+
+	p, ok := TargetOf[point](actionList[0])
+	if !ok || p != (point{1, 2}) {
+		t.Errorf("expected walk to target the closest point, got %v", actionList[0].Target())
+	}
+	if p, ok := actionList[0].(*walkAction).TypedTarget(); !ok || p != (point{1, 2}) {
+		t.Errorf("expected the typed target to be set, got %v", p)
+	}
+
+	food, ok := TargetOf[string](actionList[1])
+	if !ok || food != "apple" {
+		t.Errorf("expected eat to target the apple, got %v", actionList[1].Target())
+	}
+	if _, ok := TargetOf[point](actionList[1]); ok {
+		t.Error("expected a string target not to be a point")
+	}
+}
+
+func TestTypedAction_SetTarget_wrong_type(t *testing.T) {
+	walk := newWalkAction()
+	walk.SetTypedTarget(point{1, 2})
+	walk.SetTarget("not a point")
+	if p, ok := walk.TypedTarget(); !ok || p != (point{1, 2}) {
+		t.Errorf("expected a target of the wrong type to be ignored, got %v", walk.Target())
+	}
+}
+
+// strayWalkAction offers a target that walk can't take
+type strayWalkAction struct {
+	*walkAction
+}
+
+func (a *strayWalkAction) Targets(agent Agent) []Candidate {
+	return append(a.walkAction.Targets(agent), Candidate{Target: "nowhere"})
+}
+
+func TestTypedAction_wrong_type_candidate(t *testing.T) {
+	walk := &strayWalkAction{newWalkAction(point{3, 4})}
+	walk.AddEffect(State{"arrived", true})
+
+	goal := make(StateList)
+	goal.Add(State{"arrived", true})
+
+	actionList := Plan(&DefaultAgent{}, []Action{walk}, make(StateList), goal)
+	if len(actionList) != 1 || actionList[0].Target() != (point{3, 4}) {
+		t.Errorf("expected the candidate of the wrong type to be left out, got %v", actionList)
+	}
+}